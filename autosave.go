@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"github.com/ehsan-torabi/Draw2Matrix/core"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// autosaveInterval is how often the session snapshot is rewritten when something changed
+const autosaveInterval = time.Minute
+
+// Files of the autosave in the recovery directory of an instance
+const (
+	autosaveSnapshot = "session.zip"   // Project file of the whole session
+	autosaveJournal  = "session.jsonl" // Samples added since the snapshot was written
+	autosaveLock     = "instance.pid"  // Process ID of the running instance using the directory
+)
+
+// Autosave keeps a copy of the current session in the app data directory, so it can be
+// restored after a crash or after the main window was closed without saving.
+// Every drawn sample is appended to the journal at once. Deleting, relabelling,
+// augmenting and importing samples, which the journal cannot record, write a
+// snapshot in the background right away, and settings changes are picked up by
+// the periodic snapshot. Every running instance has its own recovery directory
+var Autosave struct {
+	dir       string // Recovery directory of this instance, empty until the autosave starts
+	journal   *core.Journal
+	journaled int    // Samples appended to the journal
+	dirty     bool   // Samples changed since the last snapshot
+	unsaved   bool   // Samples changed since the project was last saved or loaded
+	settings  []byte // Options at the last snapshot
+	writing   bool   // Whether a snapshot is being written
+	again     bool   // Whether another snapshot was asked for while writing
+	writes    sync.WaitGroup
+	pending   string // Recovery directory of a session offered for restoring and not answered yet
+}
+
+// recoveryRoot returns the directory holding the recovery directories, creating it if needed
+func recoveryRoot() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "Draw2Matrix", "recovery")
+	return dir, os.MkdirAll(dir, 0700)
+}
+
+// newRecoveryDir creates a recovery directory locked by this instance
+func newRecoveryDir() (string, error) {
+	root, err := recoveryRoot()
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(root, "session-")
+	if err != nil {
+		return "", err
+	}
+	return dir, lockRecoveryDir(dir)
+}
+
+// lockRecoveryDir marks dir as used by this instance
+func lockRecoveryDir(dir string) error {
+	return os.WriteFile(filepath.Join(dir, autosaveLock), []byte(strconv.Itoa(os.Getpid())), 0600)
+}
+
+// unlockRecoveryDir leaves dir to be recovered by the next start
+func unlockRecoveryDir(dir string) {
+	if err := os.Remove(filepath.Join(dir, autosaveLock)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Println("autosave:", err)
+	}
+}
+
+// recoveryDirLocked reports whether another running instance uses dir
+func recoveryDirLocked(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, autosaveLock))
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return err == nil && pid != os.Getpid() && processRunning(pid)
+}
+
+// processRunning reports whether a process with the given ID is running
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	defer process.Release()
+	if runtime.GOOS == "windows" {
+		// FindProcess only finds running processes on Windows
+		return true
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// startAutosave writes a snapshot of the session and opens the journal
+// It is called whenever the project settings are saved or a project is loaded
+func startAutosave() {
+	stopAutosave()
+	if Autosave.dir == "" {
+		dir, err := newRecoveryDir()
+		if err != nil {
+			log.Println("autosave:", err)
+			return
+		}
+		Autosave.dir = dir
+	}
+	var err error
+	if Autosave.journal, err = core.OpenJournal(filepath.Join(Autosave.dir, autosaveJournal)); err != nil {
+		log.Println("autosave:", err)
+		return
+	}
+	Autosave.unsaved = false
+	writeSnapshot()
+}
+
+// stopAutosave closes the journal, the autosave files stay in place
+func stopAutosave() {
+	if Autosave.journal == nil {
+		return
+	}
+	if err := Autosave.journal.Close(); err != nil {
+		log.Println("autosave:", err)
+	}
+	Autosave.journal = nil
+}
+
+// discardAutosave stops the autosave and deletes its files
+func discardAutosave() {
+	stopAutosave()
+	Autosave.writes.Wait()
+	if Autosave.dir == "" {
+		return
+	}
+	if err := os.RemoveAll(Autosave.dir); err != nil {
+		log.Println("autosave:", err)
+	}
+	Autosave.dir = ""
+}
+
+// journalSample appends a newly drawn sample to the journal
+func journalSample(sample core.Sample) {
+	if Autosave.journal == nil {
+		return
+	}
+	if err := Autosave.journal.Append(sample); err != nil {
+		log.Println("autosave:", err)
+		return
+	}
+	Autosave.journaled++
+}
+
+// markUnsaved records that the samples changed since the last snapshot and save
+func markUnsaved() {
+	Autosave.dirty = true
+	Autosave.unsaved = true
+}
+
+// writeSnapshot writes the whole session next to the journal in the background
+// It is called periodically and after every change other than a drawn sample.
+// The journal is emptied when no sample was added while the snapshot was written,
+// otherwise it is replayed on top of the snapshot, which skips the samples it has
+func writeSnapshot() {
+	if Autosave.journal == nil {
+		return
+	}
+	if Autosave.writing {
+		Autosave.again = true
+		return
+	}
+	project, err := currentProject()
+	if err != nil {
+		log.Println("autosave:", err)
+		return
+	}
+	project.Dataset = CurrentDataset.Clone()
+	dir, journaled := Autosave.dir, Autosave.journaled
+	Autosave.dirty = false
+	Autosave.writing = true
+	Autosave.writes.Add(1)
+	go func() {
+		err := writeSnapshotFile(dir, project)
+		Autosave.writes.Done()
+		fyne.Do(func() {
+			Autosave.writing = false
+			if err == nil && Autosave.journal != nil && Autosave.journaled == journaled {
+				err = Autosave.journal.Truncate()
+			}
+			if err != nil {
+				log.Println("autosave:", err)
+				Autosave.dirty = true
+			} else {
+				Autosave.settings = project.Settings
+			}
+			if Autosave.again {
+				Autosave.again = false
+				writeSnapshot()
+			}
+		})
+	}()
+}
+
+// writeSnapshotFile writes project as the snapshot of the recovery directory dir
+// The snapshot is written to a temporary file first so a crash never leaves a broken one
+func writeSnapshotFile(dir string, project core.Project) error {
+	f, err := os.CreateTemp(dir, autosaveSnapshot+".*.tmp")
+	if err != nil {
+		return err
+	}
+	err = core.WriteProject(f, project)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(dir, autosaveSnapshot))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// runAutosave rewrites the snapshot every autosaveInterval when the samples or settings changed
+func runAutosave() {
+	ticker := time.NewTicker(autosaveInterval)
+	go func() {
+		for range ticker.C {
+			fyne.Do(func() {
+				settings, err := json.Marshal(Options)
+				if Autosave.dirty || (err == nil && !bytes.Equal(settings, Autosave.settings)) {
+					writeSnapshot()
+				}
+			})
+		}
+	}()
+}
+
+// finishAutosave runs when the application quits
+// An unsaved session is kept for recovery, otherwise the autosave is deleted.
+// A session offered for restoring and not answered is kept for the next start
+func finishAutosave() {
+	if Autosave.pending != "" {
+		unlockRecoveryDir(Autosave.pending)
+		Autosave.pending = ""
+	}
+	if !Autosave.unsaved || Autosave.dir == "" {
+		discardAutosave()
+		return
+	}
+	Autosave.writes.Wait()
+	if Autosave.journal != nil {
+		project, err := currentProject()
+		if err == nil {
+			err = writeSnapshotFile(Autosave.dir, project)
+		}
+		if err == nil {
+			err = Autosave.journal.Truncate()
+		}
+		if err != nil {
+			log.Println("autosave:", err)
+		}
+	}
+	stopAutosave()
+	unlockRecoveryDir(Autosave.dir)
+}
+
+// claimRecovery takes over the most recent recovery directory that no running
+// instance uses and returns its new path, or an empty path when there is none
+// Directories left without a snapshot are deleted
+func claimRecovery() string {
+	root, err := recoveryRoot()
+	if err != nil {
+		return ""
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		log.Println("autosave:", err)
+		return ""
+	}
+	type candidate struct {
+		dir   string
+		saved time.Time
+	}
+	candidates := make([]candidate, 0)
+	for _, entry := range entries {
+		dir := filepath.Join(root, entry.Name())
+		if !entry.IsDir() || dir == Autosave.dir || recoveryDirLocked(dir) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, autosaveSnapshot))
+		if err != nil {
+			os.RemoveAll(dir)
+			continue
+		}
+		candidates = append(candidates, candidate{dir, info.ModTime()})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].saved.After(candidates[j].saved)
+	})
+	for _, c := range candidates {
+		// Renaming is atomic, so two instances starting together never claim the same session
+		claimed := filepath.Join(root, fmt.Sprintf("session-%d-%d", os.Getpid(), time.Now().UnixNano()))
+		if err := os.Rename(c.dir, claimed); err != nil {
+			continue
+		}
+		if err := lockRecoveryDir(claimed); err != nil {
+			log.Println("autosave:", err)
+		}
+		return claimed
+	}
+	return ""
+}
+
+// offerRecovery asks whether to restore a session that was not saved when an instance last quit
+func offerRecovery() {
+	dir := claimRecovery()
+	if dir == "" {
+		return
+	}
+	discard := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Println("autosave:", err)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, autosaveSnapshot))
+	var project *core.Project
+	if err == nil {
+		project, err = core.ReadProject(data)
+	}
+	if err == nil {
+		_, err = core.ReplayJournal(filepath.Join(dir, autosaveJournal), project.Dataset)
+	}
+	if err != nil {
+		log.Println("autosave:", err)
+		dialog.ShowError(fmt.Errorf("the unsaved session could not be restored: %w", err), Application.mainWindow)
+		discard()
+		return
+	}
+	if project.Dataset.Len() == 0 {
+		discard()
+		return
+	}
+
+	Autosave.pending = dir
+	message := fmt.Sprintf("An unsaved session with %d samples from %s was found.\nDo you want to restore it?",
+		project.Dataset.Len(), project.Saved.Format("2006-01-02 15:04"))
+	dialog.ShowConfirm("Restore session", message, func(b bool) {
+		Autosave.pending = ""
+		if !b {
+			discard()
+			return
+		}
+		if err := applyProjectOptions(project); err != nil {
+			dialog.ShowError(err, Application.mainWindow)
+			unlockRecoveryDir(dir)
+			return
+		}
+		// The restored session continues in its recovery directory
+		discardAutosave()
+		Autosave.dir = dir
+		restoreProject(project.Dataset)
+		applyProjectSetting(false)
+		// Still not saved to a project file, keep it for recovery until it is
+		Autosave.unsaved = true
+		statusLabel.Text = "Session restored!"
+		addLabelAnimation(statusLabel)
+	}, Application.mainWindow)
+}
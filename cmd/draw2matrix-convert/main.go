@@ -0,0 +1,178 @@
+// Command draw2matrix-convert converts image files to matrices in the Draw2Matrix
+// export formats. It only uses the core package, so it runs without a display.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ehsan-torabi/Draw2Matrix/core"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// main converts image files without starting the user interface
+func main() {
+	if err := runConvert(os.Args[1:], os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "draw2matrix-convert:", err)
+		os.Exit(1)
+	}
+}
+
+// runConvert converts image files to matrices and writes them in the given formats
+//
+//	draw2matrix-convert --rows 28 --cols 28 --format csv,npz images/*.png
+func runConvert(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("draw2matrix-convert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	rows := flags.Int("rows", 20, "number of rows in the output matrix")
+	cols := flags.Int("cols", 20, "number of columns in the output matrix")
+	formats := flags.String("format", core.FormatCSV, "comma separated output formats: "+strings.Join(core.Formats, ", "))
+	outDir := flags.String("out", ".", "output directory")
+	label := flags.String("label", "", "label for every image (default: name of the image's folder)")
+	pattern := flags.String("pattern", "", "regular expression taking the label from the file name (first capture group)")
+	normalize := flags.Bool("normalize", false, "crop images to their ink and centre it before downscaling")
+	margin := flags.Int("margin", 2, "empty cells kept around the ink with --normalize")
+	center := flags.String("center", "box", "centre the ink by its bounding \"box\" or its centre of \"mass\" with --normalize")
+	threshold := flags.String("threshold", "any", "binarization method: "+strings.Join(core.ThresholdMethodNames, ", "))
+	level := flags.Int("level", 128, "gray level below which a pixel is ink with --threshold fixed")
+	coverage := flags.Int("coverage", 25, "percentage of a cell that must be ink with --threshold coverage")
+	var settings core.ExportSettings
+	flags.StringVar(&settings.DataFileName, "data", "data", "data file name without extension")
+	flags.StringVar(&settings.TargetFileName, "target", "target", "target file name without extension")
+	flags.BoolVar(&settings.Flat, "flat", false, "flatten matrices (csv, npy, npz)")
+	flags.BoolVar(&settings.OneHot, "onehot", false, "one-hot encode targets (matlab, mat)")
+	flags.BoolVar(&settings.DotMFile, "m", false, "write .m files assigning variables (matlab)")
+	flags.BoolVar(&settings.Compress, "compress", false, "compress variables (mat)")
+	values := flags.String("values", "binary", "cell values: "+strings.Join(core.ValueModeNames, ", "))
+	flags.IntVar(&settings.Values.Levels, "levels", 4, "number of levels with --values levels")
+	flags.BoolVar(&settings.Split.Shuffle, "shuffle", false, "shuffle the samples")
+	flags.Int64Var(&settings.Split.Seed, "seed", 42, "seed of --shuffle")
+	augment := flags.Int("augment", 0, "number of augmented variants generated per image, with default ranges")
+	split := flags.String("split", "", "train/val/test ratios such as 70/15/15, stratified by label, written to _train, _val and _test files")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: draw2matrix-convert [flags] image|folder...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no images given")
+	}
+	if *rows <= 0 || *cols <= 0 {
+		return fmt.Errorf("rows and cols must be positive")
+	}
+
+	mode, err := core.ParseValueMode(*values)
+	if err != nil {
+		return err
+	}
+	settings.Values.Mode = mode
+	if *split != "" {
+		ratios := strings.Split(*split, "/")
+		if len(ratios) != 3 {
+			return fmt.Errorf("split must be train/val/test ratios, got %q", *split)
+		}
+		numbers := make([]float64, 3)
+		for i, ratio := range ratios {
+			if numbers[i], err = strconv.ParseFloat(ratio, 64); err != nil {
+				return fmt.Errorf("invalid split ratio %q", ratio)
+			}
+		}
+		settings.Split.Train, settings.Split.Val, settings.Split.Test = numbers[0], numbers[1], numbers[2]
+	}
+
+	exporters := make([]core.Exporter, 0)
+	for _, format := range strings.Split(*formats, ",") {
+		exporter, err := core.NewExporter(strings.TrimSpace(format), settings)
+		if err != nil {
+			return err
+		}
+		exporters = append(exporters, exporter)
+	}
+
+	method, err := core.ParseThresholdMethod(*threshold)
+	if err != nil {
+		return err
+	}
+	if *level < 1 || *level > 255 {
+		return fmt.Errorf("level must be between 1 and 255")
+	}
+	if *coverage < 1 || *coverage > 100 {
+		return fmt.Errorf("coverage must be between 1 and 100")
+	}
+
+	importer := core.ImageImporter{Rasterizer: core.NewRasterizer(*rows, *cols)}
+	importer.Rasterizer.Threshold = core.Threshold{Method: method, Level: uint8(*level), Coverage: float64(*coverage) / 100}
+	if *normalize {
+		if *margin < 0 {
+			return fmt.Errorf("margin must not be negative")
+		}
+		importer.Rasterizer.Normalize = &core.Normalization{Margin: *margin}
+		switch *center {
+		case "box":
+			importer.Rasterizer.Normalize.Center = core.CenterBox
+		case "mass":
+			importer.Rasterizer.Normalize.Center = core.CenterMass
+		default:
+			return fmt.Errorf("unknown centering %q", *center)
+		}
+	}
+	if *label != "" && *pattern != "" {
+		flags.Usage()
+		return fmt.Errorf("--label and --pattern cannot be used together")
+	}
+	importer.FixedLabel = *label
+	if *pattern != "" {
+		re, err := regexp.Compile(*pattern)
+		if err != nil {
+			return err
+		}
+		importer.Pattern = re
+	}
+	paths := make([]string, 0)
+	for _, arg := range flags.Args() {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		found, err := core.FindImages(arg)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, found...)
+	}
+
+	dataset, failed := importer.Import(paths, nil)
+	for path, err := range failed {
+		fmt.Fprintf(stderr, "%s: %v\n", path, err)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d images could not be converted", len(failed), len(paths))
+	}
+	if *augment > 0 {
+		augmentation := core.DefaultAugmentation
+		augmentation.Variants = *augment
+		if _, err := augmentation.Apply(dataset, importer.Rasterizer); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(*outDir, os.ModePerm); err != nil {
+		return err
+	}
+	for _, exporter := range exporters {
+		if err := exporter.Export(*outDir, dataset); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(stderr, "converted %d images to %s\n", dataset.Len(), *formats)
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestImage writes a white PNG with a black top-left quarter
+func writeTestImage(t *testing.T, path string) {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			if x >= 10 || y >= 10 {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err = png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunConvertLabels(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string // Labels in the target file, comma separated
+	}{
+		{"folder", nil, "digits,digits"},
+		{"pattern", []string{"--pattern", `^x(\d)`}, "1,2"},
+		{"label", []string{"--label", "z"}, "z,z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			images := filepath.Join(dir, "digits")
+			writeTestImage(t, filepath.Join(images, "x1.png"))
+			writeTestImage(t, filepath.Join(images, "x2.png"))
+			out := filepath.Join(dir, "out")
+			args := append([]string{"--rows", "2", "--cols", "2", "--format", "matlab", "--out", out}, tt.args...)
+			if err := runConvert(append(args, images), io.Discard); err != nil {
+				t.Fatal(err)
+			}
+			target, err := os.ReadFile(filepath.Join(out, "target.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Trim(strings.Join(strings.Fields(string(target)), ","), "[]"); got != tt.want {
+				t.Errorf("targets = %s, want %s", target, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunConvertUsageErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeTestImage(t, filepath.Join(dir, "a", "x.png"))
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no images", nil},
+		{"label and pattern", []string{"--label", "z", "--pattern", "^(x)", dir}},
+		{"unknown format", []string{"--format", "parquet", dir}},
+		{"bad split", []string{"--split", "70/30", dir}},
+		{"pattern mismatch", []string{"--pattern", "^y", dir}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"--out", filepath.Join(dir, "out")}, tt.args...)
+			if err := runConvert(args, io.Discard); err == nil {
+				t.Errorf("runConvert(%q) succeeded", tt.args)
+			}
+		})
+	}
+}
@@ -1,50 +1,26 @@
 package main
 
 import (
-	"bytes"
-	"encoding/gob"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
+	"github.com/ehsan-torabi/Draw2Matrix/core"
 	"image/color"
 	"io"
 	"log"
 	url2 "net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
-var SavedProject struct {
-	Options struct {
-		FlatMatrix           bool
-		MatlabSaveFormat     bool
-		DotMFileWithVariable bool
-		MatrixCol            int
-		MatrixRow            int
-		SettingsSaved        bool
-		OneHotEncodingSave   bool
-	}
-	TempData struct {
-		Saved      bool
-		buffer     bytes.Buffer
-		TempMatrix [][]int8
-		TempTarget []string
-	}
-	OneHotDictionary struct {
-		Dictionary map[string]interface{}
-		Values     []string
-	}
-	CounterValue   string
-	DataFilePath   string
-	TargetFilePath string
-	Buffer         []byte
-}
-
 func addLabelAnimation(obj *canvas.Text) {
 	green := color.NRGBA{G: 0xff, A: 0xff}
 	canvas.NewColorRGBAAnimation(green, color.Black, time.Second*2, func(c color.Color) {
@@ -77,44 +53,42 @@ func exportFileOperation() {
 		return
 	}
 
-	// Handle MATLAB format saving
-	if Options.MatlabSaveFormat {
-		targetFileName := targetFileEntry.Text
-		if targetFileName == "" {
-			dialog.ShowError(errors.New("target file name is empty"), Application.mainWindow)
-			return
-		}
-		if err := SaveFileForMatlab(path, dataFileName, targetFileName); err != nil {
-			statusLabel.Text = "Not Saved!"
-			return
-		}
-		statusLabel.Text = "Saved!"
+	targetFileName := targetFileEntry.Text
+	if !targetFileEntry.Disabled() && targetFileName == "" {
+		dialog.ShowError(errors.New("target file name is empty"), Application.mainWindow)
 		return
 	}
 
-	// Handle CSV format saving
-	filePath := filepath.Join(path, dataFileName+".csv")
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		// file doesn't exist, save directly
-		if err = SaveFile(path, dataFileName+".csv"); err != nil {
+	exporters := currentExporters(dataFileName, targetFileName)
+	if len(exporters) == 0 {
+		dialog.ShowError(errors.New("please select at least one save format"), Application.mainWindow)
+		return
+	}
+
+	save := func() {
+		if err := SaveDataset(path, exporters); err != nil {
+			log.Println(err)
 			statusLabel.Text = "Not Saved!"
 			return
 		}
 		statusLabel.Text = "Saved!"
-	} else {
-		// file exists, ask for confirmation
-		dialog.ShowConfirm("Warning", "file exists. Do you want to replace it?", func(b bool) {
-			if b {
-				if err = SaveFile(path, "data.csv"); err != nil {
-					statusLabel.Text = "Not Saved!"
-					return
-				}
-				statusLabel.Text = "Saved!"
-			} else {
-				statusLabel.Text = "Not Saved!"
-			}
-		}, Application.mainWindow)
 	}
+
+	existing := existingFiles(path, exporters)
+	if len(existing) == 0 {
+		// files don't exist, save directly
+		save()
+		return
+	}
+	// files exist, ask for confirmation
+	message := fmt.Sprintf("%s already exists. Do you want to replace it?", strings.Join(existing, ", "))
+	dialog.ShowConfirm("Warning", message, func(b bool) {
+		if b {
+			save()
+		} else {
+			statusLabel.Text = "Not Saved!"
+		}
+	}, Application.mainWindow)
 }
 func browseOperation() {
 	dialog.ShowFolderOpen(func(uc fyne.ListableURI, err error) {
@@ -136,20 +110,420 @@ func openPaintWindowOperation() {
 	Application.paintWindow = NewPaintWindow(mainApp, Application.paintObject)
 	Application.paintWindow.Show()
 }
+
+// importIDXOperation loads an IDX images file and its labels into the project
+// The labels file is looked up next to the images first and asked for otherwise
+func importIDXOperation() {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, Application.mainWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		reader.Close()
+		imagesPath := reader.URI().Path()
+		if labelsPath := findIDXLabels(imagesPath); labelsPath != "" {
+			importIDX(imagesPath, labelsPath)
+			return
+		}
+		dialog.ShowInformation("Import IDX", "Please select the labels (idx1-ubyte) file.", Application.mainWindow)
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, Application.mainWindow)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			reader.Close()
+			importIDX(imagesPath, reader.URI().Path())
+		}, Application.mainWindow)
+	}, Application.mainWindow)
+}
+
+// findIDXLabels returns the labels file matching an images file, or "" if there is none
+func findIDXLabels(imagesPath string) string {
+	dir, name := filepath.Split(imagesPath)
+	candidates := []string{
+		strings.Replace(name, "images-idx3", "labels-idx1", 1),
+		strings.Replace(name, "images.idx3", "labels.idx1", 1),
+		targetFileEntry.Text + "-idx1-ubyte",
+	}
+	for _, candidate := range candidates {
+		if candidate == name {
+			continue
+		}
+		path := filepath.Join(dir, candidate)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func importIDX(imagesPath, labelsPath string) {
+	imported, err := core.LoadIDX(imagesPath, labelsPath)
+	if err != nil {
+		dialog.ShowError(err, Application.mainWindow)
+		return
+	}
+	if err = mergeDataset(imported); err != nil {
+		dialog.ShowError(err, Application.mainWindow)
+		return
+	}
+	statusLabel.Text = fmt.Sprintf("Imported %d samples!", imported.Len())
+	addLabelAnimation(statusLabel)
+}
+
+// Label sources offered when importing images
+const (
+	labelFromFolder  = "Subfolder name"
+	labelFromPattern = "File name pattern"
+)
+
+// importImagesOperation imports a folder of images as labelled samples
+func importImagesOperation() {
+	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, Application.mainWindow)
+			return
+		}
+		if uri == nil {
+			return
+		}
+		root := uri.Path()
+		labelSource := widget.NewRadioGroup([]string{labelFromFolder, labelFromPattern}, nil)
+		labelSource.Required = true
+		labelSource.SetSelected(labelFromFolder)
+		patternEntry := widget.NewEntry()
+		patternEntry.SetPlaceHolder(`e.g. ^([a-z]+)_\d+`)
+		dialog.ShowForm("Import images", "Import", "Cancel", []*widget.FormItem{
+			widget.NewFormItem("Label from", labelSource),
+			widget.NewFormItem("Pattern", patternEntry),
+		}, func(b bool) {
+			if !b {
+				return
+			}
+			importer := core.ImageImporter{Rasterizer: currentRasterizer()}
+			if labelSource.Selected == labelFromPattern {
+				pattern, err := regexp.Compile(patternEntry.Text)
+				if err != nil {
+					dialog.ShowError(err, Application.mainWindow)
+					return
+				}
+				importer.Pattern = pattern
+			}
+			importImages(root, importer)
+		}, Application.mainWindow)
+	}, Application.mainWindow)
+}
+
+// importImages converts the images below root in the background and adds them to the project
+func importImages(root string, importer core.ImageImporter) {
+	paths, err := core.FindImages(root)
+	if err != nil {
+		dialog.ShowError(err, Application.mainWindow)
+		return
+	}
+	if len(paths) == 0 {
+		dialog.ShowError(fmt.Errorf("no PNG, JPEG or GIF images found"), Application.mainWindow)
+		return
+	}
+
+	progress := widget.NewProgressBar()
+	progress.Max = float64(len(paths))
+	progressDialog := dialog.NewCustomWithoutButtons("Importing images", progress, Application.mainWindow)
+	progressDialog.Show()
+	go func() {
+		imported, failed := importer.Import(paths, func(done int) {
+			fyne.Do(func() {
+				progress.SetValue(float64(done))
+			})
+		})
+		fyne.Do(func() {
+			progressDialog.Hide()
+			for path, err := range failed {
+				log.Printf("%s: %v", path, err)
+			}
+			if err := mergeDataset(imported); err != nil {
+				dialog.ShowError(err, Application.mainWindow)
+				return
+			}
+			if len(failed) > 0 {
+				dialog.ShowInformation("Import images",
+					fmt.Sprintf("%d images imported, %d skipped.", imported.Len(), len(failed)), Application.mainWindow)
+			}
+			statusLabel.Text = fmt.Sprintf("Imported %d samples!", imported.Len())
+			addLabelAnimation(statusLabel)
+		})
+	}()
+}
+
+// lastAugmentation remembers the settings last used in the augmentation dialog
+var lastAugmentation = core.DefaultAugmentation
+
+// augmentOperation asks for the augmentation settings and generates variants of every original sample
+func augmentOperation() {
+	if !Options.SettingsSaved {
+		dialog.ShowError(fmt.Errorf("please first save settings"), Application.mainWindow)
+		return
+	}
+	if CurrentDataset.Len() == 0 {
+		dialog.ShowError(fmt.Errorf("Please first add at least 1 label"), Application.mainWindow)
+		return
+	}
+
+	a := lastAugmentation
+	fields := []struct {
+		name    string
+		value   *float64
+		percent bool // Shown as a percentage of the stored fraction
+	}{
+		{"Rotation (± degrees)", &a.Rotation, false},
+		{"Scale (± %)", &a.Scale, true},
+		{"Translation (± % of size)", &a.Translate, true},
+		{"Shear (± factor)", &a.Shear, false},
+		{"Elastic distortion (± % of size)", &a.Elastic, true},
+		{"Stroke width jitter (± %)", &a.WidthJitter, true},
+		{"Pixel noise (% of cells)", &a.Noise, true},
+	}
+	variantsEntry := widget.NewEntry()
+	variantsEntry.SetText(strconv.Itoa(a.Variants))
+	seedEntry := widget.NewEntry()
+	seedEntry.SetText(strconv.FormatInt(a.Seed, 10))
+	items := []*widget.FormItem{widget.NewFormItem("Variants per sample", variantsEntry)}
+	entries := make([]*widget.Entry, len(fields))
+	for i, field := range fields {
+		value := *field.value
+		if field.percent {
+			value *= 100
+		}
+		entries[i] = widget.NewEntry()
+		entries[i].SetText(strconv.FormatFloat(value, 'f', -1, 64))
+		items = append(items, widget.NewFormItem(field.name, entries[i]))
+	}
+	items = append(items, widget.NewFormItem("Seed", seedEntry))
+
+	dialog.ShowForm("Augment samples", "Generate", "Cancel", items, func(b bool) {
+		if !b {
+			return
+		}
+		var err error
+		if a.Variants, err = strconv.Atoi(variantsEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("variants: enter number"), Application.mainWindow)
+			return
+		}
+		if a.Seed, err = strconv.ParseInt(seedEntry.Text, 10, 64); err != nil {
+			dialog.ShowError(fmt.Errorf("seed: enter number"), Application.mainWindow)
+			return
+		}
+		for i, field := range fields {
+			value, err := strconv.ParseFloat(entries[i].Text, 64)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("%s: enter number", field.name), Application.mainWindow)
+				return
+			}
+			if field.percent {
+				value /= 100
+			}
+			*field.value = value
+		}
+		if err = a.Validate(); err != nil {
+			dialog.ShowError(err, Application.mainWindow)
+			return
+		}
+		lastAugmentation = a
+		augmentSamples(a)
+	}, Application.mainWindow)
+}
+
+// augmentSamples generates the variants in the background and adds them to the project
+func augmentSamples(a core.Augmentation) {
+	samples := CurrentDataset.Samples()
+	originals := 0
+	for _, sample := range samples {
+		if !sample.Augmented() {
+			originals++
+		}
+	}
+
+	progress := widget.NewProgressBar()
+	progress.Max = float64(originals)
+	progressDialog := dialog.NewCustomWithoutButtons("Augmenting samples", progress, Application.mainWindow)
+	progressDialog.Show()
+	rasterizer := currentRasterizer()
+	go func() {
+		variants, err := a.Generate(samples, rasterizer, func(done int) {
+			fyne.Do(func() {
+				progress.SetValue(float64(done))
+			})
+		})
+		fyne.Do(func() {
+			progressDialog.Hide()
+			if err != nil {
+				dialog.ShowError(err, Application.mainWindow)
+				return
+			}
+			for _, variant := range variants {
+				if err := CurrentDataset.AddSample(variant); err != nil {
+					dialog.ShowError(err, Application.mainWindow)
+					break
+				}
+			}
+			datasetChanged()
+			writeSnapshot()
+			statusLabel.Text = fmt.Sprintf("Added %d augmented samples!", len(variants))
+			addLabelAnimation(statusLabel)
+		})
+	}()
+}
+
+// removeAugmentedOperation deletes every augmented sample after confirmation
+func removeAugmentedOperation() {
+	dialog.ShowConfirm("Remove augmented samples", "Are you sure you want to delete every augmented sample?", func(b bool) {
+		if !b {
+			return
+		}
+		removed := CurrentDataset.RemoveAugmented()
+		datasetChanged()
+		writeSnapshot()
+		statusLabel.Text = fmt.Sprintf("Removed %d augmented samples!", removed)
+		addLabelAnimation(statusLabel)
+	}, Application.mainWindow)
+}
+
+// openStatsOperation opens the statistics window of the current dataset
+func openStatsOperation() {
+	if Application.stats == nil {
+		Application.stats = NewStatsWindow(mainApp)
+	}
+	Application.stats.Show()
+}
+
+func openGalleryOperation() {
+	if Application.gallery == nil {
+		Application.gallery = NewGallery(mainApp)
+	}
+	Application.gallery.Show()
+}
+func csvSaveCheckBoxFunction(b bool) {
+	Options.CSVSaveFormat = b
+	updateFormatWidgets()
+}
 func matlabSaveCheckBoxFunction(b bool) {
 	Options.MatlabSaveFormat = b
-	if b {
-		targetFileEntry.Enable()
-		dotMFileWithVariableCheck.Enable()
-		flatMatrixCheck.Disable()
-		flatMatrixCheck.SetChecked(false)
-		Options.FlatMatrix = false
+	updateFormatWidgets()
+}
+func numpySaveCheckBoxFunction(b bool) {
+	Options.NumpySaveFormat = b
+	setEnabled(npzBundleCheck, b)
+	updateFormatWidgets()
+}
+func idxSaveCheckBoxFunction(b bool) {
+	Options.IDXSaveFormat = b
+	updateFormatWidgets()
+}
+func matFileSaveCheckBoxFunction(b bool) {
+	Options.MatFileSaveFormat = b
+	setEnabled(matCompressCheck, b)
+	updateFormatWidgets()
+}
+func npzBundleCheckBoxFunction(b bool) {
+	Options.NumpyBundle = b
+	updateFormatWidgets()
+}
+
+// updateFormatWidgets enables only the options that apply to the selected save formats
+func updateFormatWidgets() {
+	setEnabled(flatMatrixCheck, Options.CSVSaveFormat || Options.NumpySaveFormat)
+	setEnabled(dotMFileWithVariableCheck, Options.MatlabSaveFormat)
+	setEnabled(oneHotEncodingSaveCheck, Options.MatlabSaveFormat || Options.MatFileSaveFormat)
+	setEnabled(targetFileEntry, Options.MatlabSaveFormat || Options.MatFileSaveFormat || Options.IDXSaveFormat ||
+		(Options.NumpySaveFormat && !Options.NumpyBundle))
+	if Application.mainWindow != nil && Application.mainWindow.Content() != nil {
 		Application.mainWindow.Canvas().Refresh(Application.mainWindow.Content())
+	}
+}
+
+// Default number of levels for the N levels output
+const defaultValueLevels = 4
+
+// valuesSelectFunction chooses the cell values written by the exporters
+func valuesSelectFunction(s string) {
+	for i, option := range valueOptions {
+		if option == s {
+			Options.ValueMode = i
+		}
+	}
+	setEnabled(levelsInput, core.ValueMode(Options.ValueMode) == core.LevelValues)
+}
+
+func levelsValidator(s string) error {
+	val, err := strconv.Atoi(s)
+	if err != nil || val < 2 || val > 256 {
+		return fmt.Errorf("enter number between 2 and 256")
+	}
+	Options.ValueLevels = val
+	return nil
+}
+
+// Defaults of the shuffle and split settings
+const (
+	defaultSplitSeed  = 42
+	defaultSplitTrain = 70
+	defaultSplitVal   = 15
+	defaultSplitTest  = 15
+)
+
+func shuffleCheckBoxFunction(b bool) {
+	Options.ShuffleExport = b
+	updateSplitWidgets()
+}
+func splitCheckBoxFunction(b bool) {
+	Options.SplitExport = b
+	updateSplitWidgets()
+}
+
+// updateSplitWidgets shows the split settings and enables the entries of the checked options
+func updateSplitWidgets() {
+	seedInput.SetText(strconv.Itoa(Options.SplitSeed))
+	trainInput.SetText(strconv.Itoa(Options.SplitTrain))
+	valInput.SetText(strconv.Itoa(Options.SplitVal))
+	testInput.SetText(strconv.Itoa(Options.SplitTest))
+	setEnabled(seedInput, Options.ShuffleExport)
+	for _, entry := range []*widget.Entry{trainInput, valInput, testInput} {
+		setEnabled(entry, Options.SplitExport)
+	}
+}
+
+func seedValidator(s string) error {
+	val, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("enter number")
+	}
+	Options.SplitSeed = val
+	return nil
+}
+
+// ratioValidator returns a validator storing a split percentage in target
+func ratioValidator(target *int) func(string) error {
+	return func(s string) error {
+		val, err := strconv.Atoi(s)
+		if err != nil || val < 0 || val > 100 {
+			return fmt.Errorf("enter percentage between 0 and 100")
+		}
+		*target = val
+		return nil
+	}
+}
+
+func setEnabled(w fyne.Disableable, enabled bool) {
+	if enabled {
+		w.Enable()
 	} else {
-		dotMFileWithVariableCheck.Disable()
-		targetFileEntry.Disable()
-		flatMatrixCheck.Enable()
-		Application.mainWindow.Canvas().Refresh(Application.mainWindow.Content())
+		w.Disable()
 	}
 }
 
@@ -163,21 +537,13 @@ func expertPNGOperation() {
 	if input.Text != "" {
 		filename = input.Text + ".png"
 	}
-	err := Application.paintObject.ExportToPNG(Application.paintWindow, filename)
+	err := Application.paintObject.ExportToPNG(filename)
 	if err != nil {
 		fmt.Printf("Export error: %s", err)
 	}
 }
 func oneHotEncodingCheckBoxFunction(b bool) {
 	Options.OneHotEncodingSave = b
-	if b {
-		flatMatrixCheck.Disable()
-		flatMatrixCheck.SetChecked(false)
-		matlabSaveCheck.SetChecked(true)
-	} else {
-		flatMatrixCheck.Enable()
-	}
-
 }
 func addButtonFunction() {
 	if !Options.SettingsSaved {
@@ -185,65 +551,72 @@ func addButtonFunction() {
 		return
 	}
 	if input.Text != "" {
-		if Options.MatlabSaveFormat {
-			AddToFileForMatlab(Application.paintObject.GetMatrix(Application.paintWindow), input.Text)
-			count, err := strconv.Atoi(counterLabel.Text)
-			if err != nil {
-				return
-			}
-			counterLabel.SetText(strconv.Itoa(count + 1))
-			addLabelAnimation(statusLabel)
-			statusLabel.Text = "Added!"
-			return
-		}
-		err := AddToFile(Application.paintObject.GetMatrix(Application.paintWindow), input.Text)
+		paint := Application.paintObject
+		err := AddToDataset(paint.GetMatrix(), paint.GetInk(), paint.Drawing(), input.Text)
 		if err != nil {
 			dialog.ShowError(fmt.Errorf("error to add matrix"), Application.mainWindow)
 			return
 		}
-		count, err := strconv.Atoi(counterLabel.Text)
-		if err != nil {
-			return
-		}
-		counterLabel.SetText(strconv.Itoa(count + 1))
+		datasetChanged()
 		addLabelAnimation(statusLabel)
 		statusLabel.Text = "Added!"
+		nextSessionLabel()
 		return
 	}
 	dialog.ShowError(fmt.Errorf("please enter valid label"), Application.mainWindow)
 }
+
+// datasetChanged updates the counter, any open gallery or statistics, the session progress,
+// the live prediction and the autosave state after samples were added, removed or relabelled
+func datasetChanged() {
+	markUnsaved()
+	counterLabel.SetText(strconv.Itoa(CurrentDataset.Len()))
+	if Application.gallery != nil {
+		Application.gallery.Refresh()
+	}
+	if Application.session != nil {
+		Application.session.Refresh()
+	}
+	if Application.stats != nil {
+		Application.stats.Refresh()
+	}
+	retrainPrediction()
+}
+
+// applyProjectSetting locks the matrix size for the project
+// Save formats stay editable since every format is generated at save time
 func applyProjectSetting(withInitial bool) {
 	rowInput.Disable()
 	colInput.Disable()
-	flatMatrixCheck.Disable()
-	matlabSaveCheck.Disable()
-	dotMFileWithVariableCheck.Disable()
-	oneHotEncodingSaveCheck.Disable()
+	normalizeCheck.Disable()
+	marginInput.Disable()
+	centerSelect.Disable()
+	thresholdSelect.Disable()
+	updateThresholdWidgets()
 	Options.SettingsSaved = true
 	if withInitial {
-		InitializeTemps()
+		InitializeDataset()
 	}
+	startAutosave()
 
 }
 func resetProjectSetting() {
 	dialog.ShowConfirm("Warning", "Are you sure you want to do that?\nthis is delete your added matrix if you dont saves it. ",
 		func(choice bool) {
+			if !choice {
+				return
+			}
 			rowInput.Enable()
 			colInput.Enable()
-			flatMatrixCheck.Enable()
-			matlabSaveCheck.Enable()
-			dotMFileWithVariableCheck.Enable()
-			oneHotEncodingSaveCheck.Enable()
-			counterLabel.SetText("0")
+			normalizeCheck.Enable()
+			marginInput.Enable()
+			centerSelect.Enable()
+			thresholdSelect.Enable()
+			updateThresholdWidgets()
 			Options.SettingsSaved = false
-			TempData.TempTarget = nil
-			TempData.TempMatrix = nil
-			OneHotDictionary.Dictionary = nil
-			OneHotDictionary.Values = nil
-			if &TempData.buffer != nil {
-				TempData.buffer = bytes.Buffer{}
-				TempData.buffer.Reset()
-			}
+			CurrentDataset.Reset()
+			discardAutosave()
+			datasetChanged()
 
 		}, Application.mainWindow,
 	)
@@ -277,6 +650,7 @@ func rowValidator(s string) error {
 		return fmt.Errorf("enter number ")
 	}
 	Options.MatrixRow = val + 1
+	refreshPreviews()
 	return nil
 }
 func colValidator(s string) error {
@@ -285,64 +659,360 @@ func colValidator(s string) error {
 		return fmt.Errorf("enter number")
 	}
 	Options.MatrixCol = val + 1
+	refreshPreviews()
+	return nil
+}
+func marginValidator(s string) error {
+	val, err := strconv.Atoi(s)
+	if err != nil || val < 0 {
+		return fmt.Errorf("enter number")
+	}
+	Options.NormalizeMargin = val
+	refreshPreviews()
 	return nil
 }
+
+// centerSelectFunction chooses how normalized ink is centred
+func centerSelectFunction(s string) {
+	Options.CenterOfMass = s == centerOptions[1]
+	refreshPreviews()
+}
+
+// Defaults of the threshold parameters, also used for projects saved before they existed
+const (
+	defaultThresholdLevel    = 128
+	defaultThresholdCoverage = 25
+)
+
+// thresholdSelectFunction chooses the binarization method
+func thresholdSelectFunction(s string) {
+	for i, option := range thresholdOptions {
+		if option == s {
+			Options.ThresholdMethod = i
+		}
+	}
+	updateThresholdWidgets()
+	refreshPreviews()
+}
+
+// updateThresholdWidgets enables the parameter entry of the selected threshold method
+// while the project settings are editable
+func updateThresholdWidgets() {
+	method := core.ThresholdMethod(Options.ThresholdMethod)
+	setEnabled(thresholdLevelInput, !Options.SettingsSaved && method == core.FixedThreshold)
+	setEnabled(coverageInput, !Options.SettingsSaved && method == core.CoverageThreshold)
+}
+
+func thresholdLevelValidator(s string) error {
+	val, err := strconv.Atoi(s)
+	if err != nil || val < 1 || val > 255 {
+		return fmt.Errorf("enter number between 1 and 255")
+	}
+	Options.ThresholdLevel = val
+	refreshPreviews()
+	return nil
+}
+
+func coverageValidator(s string) error {
+	val, err := strconv.Atoi(s)
+	if err != nil || val < 1 || val > 100 {
+		return fmt.Errorf("enter percentage between 1 and 100")
+	}
+	Options.ThresholdCoverage = val
+	refreshPreviews()
+	return nil
+}
+
+// openThresholdPreviewOperation shows the current drawing binarized with every threshold method
+func openThresholdPreviewOperation() {
+	if Application.thresholds == nil {
+		Application.thresholds = NewThresholdPreview(mainApp)
+	}
+	Application.thresholds.Show()
+}
+
+// refreshPreviews re-runs the conversion shown by the matrix preview and open preview windows
+// after the drawing or a conversion setting changed
+func refreshPreviews() {
+	rows, cols := matrixSize()
+	if Application.paintObject == nil || rows <= 0 || cols <= 0 {
+		return
+	}
+	matrix := Application.paintObject.GetMatrix()
+	ink := 0
+	for _, v := range core.Flatten(matrix) {
+		ink += int(v)
+	}
+	matrixPreview.SetMatrix(matrix)
+	previewInfo.SetText(fmt.Sprintf("%d of %d cells set", ink, rows*cols))
+	if Application.thresholds != nil {
+		Application.thresholds.Refresh()
+	}
+	updatePrediction(matrix, ink)
+}
+
+// defaultPredictK is the default number of neighbours of the live prediction
+const defaultPredictK = 5
+
+// updatePrediction classifies the current drawing with k-NN on the current dataset
+// and shows the top 3 labels. matrix is the converted drawing with ink cells set
+func updatePrediction(matrix [][]int8, ink int) {
+	if !Options.Predict {
+		predictionLabel.SetText("")
+		return
+	}
+	if CurrentDataset.Len() == 0 {
+		predictionLabel.SetText("No samples to compare with yet")
+		return
+	}
+	if ink == 0 {
+		predictionLabel.SetText("Draw to see the prediction")
+		return
+	}
+	if Application.classifier == nil {
+		classifier, err := core.NewKNN(Options.PredictK, core.Distance(Options.PredictDistance))
+		if err != nil {
+			predictionLabel.SetText(err.Error())
+			return
+		}
+		classifier.Train(CurrentDataset)
+		Application.classifier = classifier
+	}
+	sample := core.Sample{Matrix: matrix}
+	if Application.classifier.Distance == core.EuclideanDistance {
+		sample.Ink = Application.paintObject.GetInk()
+	}
+	predictions := Application.classifier.Predict(sample, 3)
+	lines := make([]string, len(predictions))
+	for i, prediction := range predictions {
+		lines[i] = fmt.Sprintf("%d. %s  %.0f%%", i+1, prediction.Label, prediction.Confidence*100)
+	}
+	if core.Ambiguous(predictions) {
+		lines = append(lines, fmt.Sprintf("⚠ %s and %s look alike", predictions[0].Label, predictions[1].Label))
+	}
+	predictionLabel.SetText(strings.Join(lines, "\n"))
+}
+
+// retrainPrediction trains the live prediction again and updates it
+// after the samples or the classifier settings changed
+func retrainPrediction() {
+	Application.classifier = nil
+	refreshPreviews()
+}
+
+func predictCheckBoxFunction(b bool) {
+	Options.Predict = b
+	setEnabled(predictKInput, b)
+	setEnabled(distanceSelect, b)
+	refreshPreviews()
+}
+
+func distanceSelectFunction(s string) {
+	for i, option := range distanceOptions {
+		if option == s {
+			Options.PredictDistance = i
+		}
+	}
+	retrainPrediction()
+}
+
+func predictKValidator(s string) error {
+	val, err := strconv.Atoi(s)
+	if err != nil || val < 1 {
+		return fmt.Errorf("enter number")
+	}
+	Options.PredictK = val
+	retrainPrediction()
+	return nil
+}
+
 func onStartedApplication() {
-	// Temporarily disable stdout to prevent matrix printing
-	oldStdOut := os.Stdout
-	os.Stdout = nil
-	Application.paintObject.PrintMatrix(Application.paintWindow, Options.FlatMatrix)
-	os.Stdout = oldStdOut
+	refreshPreviews()
+	runAutosave()
+	offerRecovery()
 }
 
-func prepareSaveProjectObj() {
-	SavedProject.Options = Options
-	SavedProject.TempData = TempData
-	SavedProject.OneHotDictionary = OneHotDictionary
-	SavedProject.CounterValue = counterLabel.Text
-	SavedProject.Buffer = TempData.buffer.Bytes()
+func onStoppedApplication() {
+	finishPreferences()
+	finishAutosave()
+}
 
+// currentProject returns the current dataset with the settings to store in a project file
+func currentProject() (core.Project, error) {
+	settings, err := json.Marshal(Options)
+	return core.Project{Saved: time.Now(), Settings: settings, Dataset: CurrentDataset}, err
+}
+
+// readProject reads a zip project file and sets Options from its settings
+func readProject(data []byte) (*core.Dataset, error) {
+	project, err := core.ReadProject(data)
+	if err != nil {
+		return nil, err
+	}
+	return project.Dataset, applyProjectOptions(project)
 }
 
+// applyProjectOptions sets Options from the settings of a project
+// Settings missing from the project keep their current value
+func applyProjectOptions(project *core.Project) error {
+	settings := Options
+	if len(project.Settings) > 0 {
+		if err := json.Unmarshal(project.Settings, &settings); err != nil {
+			return err
+		}
+	}
+	settings.MatrixRow, settings.MatrixCol = project.Dataset.Rows+1, project.Dataset.Cols+1
+	Options = settings
+	return nil
+}
+
+// readLegacyProject reads a gob project file of older versions and sets Options from it
+// Settings the old format did not have take the defaults of new sessions.
+// Saving the project again writes the zip format
+func readLegacyProject(data []byte) (*core.Dataset, error) {
+	project, err := core.ReadLegacyProject(data)
+	if err != nil {
+		return nil, err
+	}
+	dataset, err := project.Dataset()
+	if err != nil {
+		return nil, err
+	}
+	settings := builtinOptions
+	readDefaults(&settings)
+	settings.FlatMatrix = project.Options.FlatMatrix
+	settings.MatlabSaveFormat = project.Options.MatlabSaveFormat
+	settings.DotMFileWithVariable = project.Options.DotMFileWithVariable
+	settings.MatrixCol = project.Options.MatrixCol
+	settings.MatrixRow = project.Options.MatrixRow
+	settings.SettingsSaved = project.Options.SettingsSaved
+	settings.OneHotEncodingSave = project.Options.OneHotEncodingSave
+	// Older versions saved either MATLAB or CSV files
+	settings.CSVSaveFormat = !settings.MatlabSaveFormat
+	settings.NumpySaveFormat, settings.MatFileSaveFormat, settings.IDXSaveFormat = false, false, false
+	Options = settings
+	return dataset, nil
+}
+
+// loadProjectFile reads a project file in the zip format or the gob format of older versions
+// and restores its settings and samples
 func loadProjectFile(reader io.ReadCloser) error {
-	decoder := gob.NewDecoder(reader)
-	err := decoder.Decode(&SavedProject)
+	data, err := io.ReadAll(reader)
+	reader.Close()
 	if err != nil {
 		return err
 	}
-	Options = SavedProject.Options
-	TempData = SavedProject.TempData
-	copy(TempData.TempMatrix, SavedProject.TempData.TempMatrix)
-	copy(TempData.TempTarget, SavedProject.TempData.TempTarget)
-	TempData.buffer.Write(SavedProject.Buffer)
-	OneHotDictionary = SavedProject.OneHotDictionary
-	copy(OneHotDictionary.Values, SavedProject.OneHotDictionary.Values)
-	err = countValue.Set(SavedProject.CounterValue)
+	var dataset *core.Dataset
+	if core.IsProjectArchive(data) {
+		dataset, err = readProject(data)
+	} else {
+		dataset, err = readLegacyProject(data)
+	}
 	if err != nil {
 		log.Println(err)
 		return err
 	}
+	restoreProject(dataset)
+	return nil
+}
+
+// restoreProject makes dataset the current dataset and shows the settings of Options in the widgets
+func restoreProject(dataset *core.Dataset) {
+	CurrentDataset = dataset
+	datasetChanged()
+	showOptions()
+}
+
+// showOptions shows the settings of Options in the widgets
+// Settings missing from older projects get their defaults
+func showOptions() {
 	rowInput.Text = strconv.Itoa(Options.MatrixRow - 1)
 	colInput.Text = strconv.Itoa(Options.MatrixCol - 1)
+	marginInput.Text = strconv.Itoa(Options.NormalizeMargin)
+	normalizeCheck.SetChecked(Options.NormalizeInk)
+	if Options.ThresholdLevel == 0 {
+		Options.ThresholdLevel = defaultThresholdLevel
+	}
+	if Options.ThresholdCoverage == 0 {
+		Options.ThresholdCoverage = defaultThresholdCoverage
+	}
+	thresholdLevelInput.Text = strconv.Itoa(Options.ThresholdLevel)
+	coverageInput.Text = strconv.Itoa(Options.ThresholdCoverage)
+	if Options.ThresholdMethod < 0 || Options.ThresholdMethod >= len(thresholdOptions) {
+		Options.ThresholdMethod = int(core.AnyInk)
+	}
+	thresholdSelect.SetSelected(thresholdOptions[Options.ThresholdMethod])
+	if Options.ValueLevels == 0 {
+		Options.ValueLevels = defaultValueLevels
+	}
+	if Options.ValueMode < 0 || Options.ValueMode >= len(valueOptions) {
+		Options.ValueMode = int(core.BinaryValues)
+	}
+	levelsInput.Text = strconv.Itoa(Options.ValueLevels)
+	if Options.SplitTrain == 0 && Options.SplitVal == 0 && Options.SplitTest == 0 {
+		Options.SplitSeed = defaultSplitSeed
+		Options.SplitTrain, Options.SplitVal, Options.SplitTest = defaultSplitTrain, defaultSplitVal, defaultSplitTest
+	}
+	shuffleCheck.SetChecked(Options.ShuffleExport)
+	skipAugmentedCheck.SetChecked(Options.ExcludeAugmented)
+	splitCheck.SetChecked(Options.SplitExport)
+	updateSplitWidgets()
+	valuesSelect.SetSelected(valueOptions[Options.ValueMode])
+	if Options.CenterOfMass {
+		centerSelect.SetSelected(centerOptions[1])
+	} else {
+		centerSelect.SetSelected(centerOptions[0])
+	}
 	oneHotEncodingSaveCheck.SetChecked(Options.OneHotEncodingSave)
+	csvSaveCheck.SetChecked(Options.CSVSaveFormat)
 	matlabSaveCheck.SetChecked(Options.MatlabSaveFormat)
+	numpySaveCheck.SetChecked(Options.NumpySaveFormat)
+	npzBundleCheck.SetChecked(Options.NumpyBundle)
+	matFileSaveCheck.SetChecked(Options.MatFileSaveFormat)
+	matCompressCheck.SetChecked(Options.MatFileCompress)
+	idxSaveCheck.SetChecked(Options.IDXSaveFormat)
 	dotMFileWithVariableCheck.SetChecked(Options.DotMFileWithVariable)
 	flatMatrixCheck.SetChecked(Options.FlatMatrix)
+	if Options.PredictK == 0 {
+		Options.PredictK = defaultPredictK
+	}
+	if Options.PredictDistance < 0 || Options.PredictDistance >= len(distanceOptions) {
+		Options.PredictDistance = int(core.HammingDistance)
+	}
+	predictKInput.Text = strconv.Itoa(Options.PredictK)
+	distanceSelect.SetSelected(distanceOptions[Options.PredictDistance])
+	predictCheck.SetChecked(Options.Predict)
+	if Options.SavePath == "" {
+		Options.SavePath = savePath.Text
+	}
+	if Options.DataFileName == "" {
+		Options.DataFileName = dataFileEntry.Text
+	}
+	if Options.TargetFileName == "" {
+		Options.TargetFileName = targetFileEntry.Text
+	}
+	savePath.SetText(Options.SavePath)
+	dataFileEntry.SetText(Options.DataFileName)
+	targetFileEntry.SetText(Options.TargetFileName)
 	Application.mainWindow.Content().Refresh()
-	return nil
 }
 
 func saveProjectFileFunction() {
 	dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
 		if !Options.SettingsSaved {
 			dialog.ShowError(fmt.Errorf("Please first save project settings."), Application.mainWindow)
 			return
 		}
-		prepareSaveProjectObj()
-		encoder := gob.NewEncoder(writer)
-		err = encoder.Encode(SavedProject)
+		project, err := currentProject()
+		if err == nil {
+			err = core.WriteProject(writer, project)
+		}
 		if err != nil {
 			log.Println(err)
+			dialog.ShowError(fmt.Errorf("error saving project file"), Application.mainWindow)
 			return
 		}
 
@@ -350,6 +1020,7 @@ func saveProjectFileFunction() {
 		if err != nil {
 			return
 		}
+		Autosave.unsaved = false
 
 	}, Application.mainWindow)
 
@@ -357,7 +1028,9 @@ func saveProjectFileFunction() {
 
 func loadProjectFileFunction() {
 	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
-
+		if err != nil || reader == nil {
+			return
+		}
 		if Options.SettingsSaved {
 			dialog.ShowConfirm("Warning", "Are you sure to load project? Your current session is removed if you dont saved it.", func(b bool) {
 				if b {
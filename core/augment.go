@@ -0,0 +1,225 @@
+package core
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// elasticGrid is the number of random displacements per axis of the elastic distortion
+// The displacement between them is interpolated, which keeps the distortion smooth
+const elasticGrid = 4
+
+// matrixUpscale is the number of pixels per cell when a sample without strokes is augmented
+const matrixUpscale = 8
+
+// Augmentation configures the random variants generated from collected samples
+// Every range is symmetric: a Rotation of 10 turns the variants by -10 to +10 degrees
+type Augmentation struct {
+	Variants    int     // Number of variants generated per sample
+	Rotation    float64 // Maximum rotation in degrees
+	Scale       float64 // Maximum relative size change, 0.1 for 90% to 110%
+	Translate   float64 // Maximum shift as a fraction of the image size
+	Shear       float64 // Maximum horizontal shear factor
+	Elastic     float64 // Maximum elastic displacement as a fraction of the image size
+	WidthJitter float64 // Maximum relative stroke width change, for samples with strokes
+	Noise       float64 // Probability of flipping each cell
+	Seed        int64   // Seed of the random generator, the same seed gives the same variants
+}
+
+// DefaultAugmentation holds moderate settings suited to hand-drawn symbols
+var DefaultAugmentation = Augmentation{
+	Variants:    5,
+	Rotation:    10,
+	Scale:       0.1,
+	Translate:   0.1,
+	Shear:       0.1,
+	Elastic:     0.02,
+	WidthJitter: 0.2,
+	Seed:        1,
+}
+
+// Validate returns an error when a setting is out of range
+func (a Augmentation) Validate() error {
+	if a.Variants < 1 {
+		return fmt.Errorf("variants must be at least 1")
+	}
+	if a.Rotation < 0 || a.Translate < 0 || a.Shear < 0 || a.Elastic < 0 {
+		return fmt.Errorf("augmentation ranges must not be negative")
+	}
+	if a.Scale < 0 || a.Scale >= 1 || a.WidthJitter < 0 || a.WidthJitter >= 1 {
+		return fmt.Errorf("scale and width jitter must be from 0 up to 1")
+	}
+	if a.Noise < 0 || a.Noise > 1 {
+		return fmt.Errorf("noise must be a probability from 0 to 1")
+	}
+	return nil
+}
+
+// Generate returns Variants variants of every original in samples, converted with r
+// The variants are tagged with the ID of their original. progress, when not
+// nil, is called after each original with the number of originals done
+func (a Augmentation) Generate(samples []Sample, r *Rasterizer, progress func(done int)) ([]Sample, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	random := rand.New(rand.NewSource(a.Seed))
+	variants := make([]Sample, 0)
+	done := 0
+	for _, s := range samples {
+		if s.Augmented() {
+			continue
+		}
+		for i := 0; i < a.Variants; i++ {
+			variants = append(variants, a.Variant(s, r, random))
+		}
+		done++
+		if progress != nil {
+			progress(done)
+		}
+	}
+	return variants, nil
+}
+
+// Apply adds the variants of every original sample of d and returns how many were added
+func (a Augmentation) Apply(d *Dataset, r *Rasterizer) (int, error) {
+	variants, err := a.Generate(d.Samples(), r, nil)
+	if err != nil {
+		return 0, err
+	}
+	for i, variant := range variants {
+		if err = d.AddSample(variant); err != nil {
+			return i, err
+		}
+	}
+	return len(variants), nil
+}
+
+// Variant returns one random variant of s converted with r
+// Samples with strokes are re-rendered with jittered stroke widths, other
+// samples are distorted from their ink intensities or binary matrix
+func (a Augmentation) Variant(s Sample, r *Rasterizer, random *rand.Rand) Sample {
+	var source *image.Gray
+	if s.Drawing != nil {
+		drawing := *s.Drawing
+		drawing.Strokes = make([]Stroke, len(s.Drawing.Strokes))
+		for i, stroke := range s.Drawing.Strokes {
+			stroke.Width *= float32(1 + uniform(random, a.WidthJitter))
+			drawing.Strokes[i] = stroke
+		}
+		source = drawing.Render(int(drawing.Width), int(drawing.Height))
+	} else {
+		source = inkImage(s, matrixUpscale)
+	}
+
+	variant := r.Sample(a.warp(source, random), s.Label)
+	for y, row := range variant.Matrix {
+		for x := range row {
+			if a.Noise > 0 && random.Float64() < a.Noise {
+				variant.Matrix[y][x] = 1 - variant.Matrix[y][x]
+				variant.Ink[y][x] = 255 - variant.Ink[y][x]
+			}
+		}
+	}
+	variant.Parent = s.ID
+	variant.Time = time.Now()
+	return variant
+}
+
+// uniform returns a random number from -max to max
+func uniform(random *rand.Rand, max float64) float64 {
+	return (random.Float64()*2 - 1) * max
+}
+
+// inkImage draws the ink of a sample with scale x scale pixels per cell
+// Samples without ink intensities are drawn from their binary matrix
+func inkImage(s Sample, scale int) *image.Gray {
+	rows := len(s.Matrix)
+	cols := 0
+	if rows > 0 {
+		cols = len(s.Matrix[0])
+	}
+	img := image.NewGray(image.Rect(0, 0, cols*scale, rows*scale))
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			ink := uint8(0)
+			if s.Ink != nil {
+				ink = s.Ink[y/scale][x/scale]
+			} else if s.Matrix[y/scale][x/scale] != 0 {
+				ink = 255
+			}
+			img.Pix[y*img.Stride+x] = 255 - ink
+		}
+	}
+	return img
+}
+
+// warp returns img turned, scaled, sheared, shifted and elastically distorted
+// by random amounts. Areas moved in from outside the image are white paper
+func (a Augmentation) warp(img *image.Gray, random *rand.Rand) *image.Gray {
+	w, h := float64(img.Rect.Dx()), float64(img.Rect.Dy())
+	size := math.Max(w, h)
+	angle := uniform(random, a.Rotation) * math.Pi / 180
+	scale := 1 + uniform(random, a.Scale)
+	shear := uniform(random, a.Shear)
+	tx, ty := uniform(random, a.Translate)*w, uniform(random, a.Translate)*h
+	var field [elasticGrid][elasticGrid][2]float64
+	for i := range field {
+		for j := range field[i] {
+			field[i][j] = [2]float64{uniform(random, a.Elastic*size), uniform(random, a.Elastic*size)}
+		}
+	}
+
+	// The forward map is q = c + t + scale * rotation * shear * (p - c),
+	// every output pixel q is looked up at p through the inverse of that matrix
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	m00, m01 := scale*cos, scale*(cos*shear-sin)
+	m10, m11 := scale*sin, scale*(sin*shear+cos)
+	det := m00*m11 - m01*m10
+	cx, cy := w/2, h/2
+
+	out := image.NewGray(image.Rect(0, 0, img.Rect.Dx(), img.Rect.Dy()))
+	for y := 0; y < out.Rect.Dy(); y++ {
+		for x := 0; x < out.Rect.Dx(); x++ {
+			qx, qy := float64(x)+0.5, float64(y)+0.5
+			dx, dy := interpolateField(&field, qx/w, qy/h)
+			ux, uy := qx-cx-tx-dx, qy-cy-ty-dy
+			px := (m11*ux-m01*uy)/det + cx
+			py := (m00*uy-m10*ux)/det + cy
+			out.Pix[y*out.Stride+x] = bilinear(img, px-0.5, py-0.5)
+		}
+	}
+	return out
+}
+
+// interpolateField returns the elastic displacement at u, v from 0 to 1
+func interpolateField(field *[elasticGrid][elasticGrid][2]float64, u, v float64) (float64, float64) {
+	gx := math.Min(math.Max(u*(elasticGrid-1), 0), elasticGrid-1)
+	gy := math.Min(math.Max(v*(elasticGrid-1), 0), elasticGrid-1)
+	x0, y0 := int(math.Min(gx, elasticGrid-2)), int(math.Min(gy, elasticGrid-2))
+	fx, fy := gx-float64(x0), gy-float64(y0)
+	var d [2]float64
+	for k := range d {
+		top := field[y0][x0][k]*(1-fx) + field[y0][x0+1][k]*fx
+		bottom := field[y0+1][x0][k]*(1-fx) + field[y0+1][x0+1][k]*fx
+		d[k] = top*(1-fy) + bottom*fy
+	}
+	return d[0], d[1]
+}
+
+// bilinear samples img between pixel centres, outside the image is white
+func bilinear(img *image.Gray, x, y float64) uint8 {
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+	at := func(x, y int) float64 {
+		if x < 0 || y < 0 || x >= img.Rect.Dx() || y >= img.Rect.Dy() {
+			return 255
+		}
+		return float64(img.Pix[y*img.Stride+x])
+	}
+	top := at(x0, y0)*(1-fx) + at(x0+1, y0)*fx
+	bottom := at(x0, y0+1)*(1-fx) + at(x0+1, y0+1)*fx
+	return uint8(math.Round(top*(1-fy) + bottom*fy))
+}
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// Sample is a single labelled matrix collected by the user
+type Sample struct {
+	Matrix  [][]int8  // Binary matrix, Rows x Cols
+	Ink     [][]uint8 // Ink intensity of every cell from 0 (paper) to 255, nil if unknown
+	Label   string    // Class name of the sample
+	Drawing *Drawing  // Strokes the matrix was made from, nil if unknown
+	Time    time.Time // When the sample was collected, zero if unknown
+	ID      uint64    // Identifier assigned by the dataset, unique within it
+	Parent  uint64    // ID of the sample an augmented variant was generated from, 0 for originals
+}
+
+// Augmented reports whether the sample was generated by augmentation
+func (s Sample) Augmented() bool {
+	return s.Parent != 0
+}
+
+// Dataset holds the samples collected for one project
+// All samples share the same matrix dimensions. Samples are kept independent
+// of any file format, every Exporter is generated from the same data.
+type Dataset struct {
+	Rows    int
+	Cols    int
+	samples []Sample
+	classes []string // Label order inherited from the dataset a subset was taken from
+	lastID  uint64   // Largest sample ID in use
+}
+
+// NewDataset creates an empty dataset for matrices of the given size
+func NewDataset(rows, cols int) *Dataset {
+	return &Dataset{Rows: rows, Cols: cols, samples: make([]Sample, 0)}
+}
+
+// Add appends a matrix with its label to the dataset
+func (d *Dataset) Add(matrix [][]int8, label string) error {
+	return d.AddSample(Sample{Matrix: matrix, Label: label})
+}
+
+// AddSample appends a sample to the dataset
+// The matrix must match the dimensions of the dataset. A sample without an ID
+// gets the next free one, a sample with an ID keeps it
+func (d *Dataset) AddSample(s Sample) error {
+	if s.Label == "" {
+		return fmt.Errorf("empty label")
+	}
+	if len(s.Matrix) != d.Rows {
+		return fmt.Errorf("matrix has %d rows, want %d", len(s.Matrix), d.Rows)
+	}
+	for _, row := range s.Matrix {
+		if len(row) != d.Cols {
+			return fmt.Errorf("matrix has %d columns, want %d", len(row), d.Cols)
+		}
+	}
+	if s.Ink != nil {
+		if len(s.Ink) != d.Rows {
+			return fmt.Errorf("ink has %d rows, want %d", len(s.Ink), d.Rows)
+		}
+		for _, row := range s.Ink {
+			if len(row) != d.Cols {
+				return fmt.Errorf("ink has %d columns, want %d", len(row), d.Cols)
+			}
+		}
+	}
+	if s.ID == 0 {
+		s.ID = d.lastID + 1
+	} else if s.ID <= d.lastID {
+		for _, other := range d.samples {
+			if other.ID == s.ID {
+				return fmt.Errorf("duplicate sample ID %d", s.ID)
+			}
+		}
+	}
+	if s.ID > d.lastID {
+		d.lastID = s.ID
+	}
+	d.samples = append(d.samples, s)
+	return nil
+}
+
+// checkIndex returns an error when i is not a valid sample index
+func (d *Dataset) checkIndex(i int) error {
+	if i < 0 || i >= len(d.samples) {
+		return fmt.Errorf("sample %d out of range", i)
+	}
+	return nil
+}
+
+// Sample returns the sample at index i
+func (d *Dataset) Sample(i int) (Sample, error) {
+	if err := d.checkIndex(i); err != nil {
+		return Sample{}, err
+	}
+	return d.samples[i], nil
+}
+
+// Remove deletes the sample at index i together with its augmented variants
+func (d *Dataset) Remove(i int) error {
+	if err := d.checkIndex(i); err != nil {
+		return err
+	}
+	id := d.samples[i].ID
+	d.removeIf(func(s Sample) bool {
+		return s.ID == id || s.Parent == id
+	})
+	return nil
+}
+
+// RemoveAugmented deletes every augmented sample and returns how many were removed
+func (d *Dataset) RemoveAugmented() int {
+	return d.removeIf(Sample.Augmented)
+}
+
+// removeIf deletes the samples matching remove and returns how many were removed
+func (d *Dataset) removeIf(remove func(Sample) bool) int {
+	kept := d.samples[:0]
+	for _, s := range d.samples {
+		if !remove(s) {
+			kept = append(kept, s)
+		}
+	}
+	removed := len(d.samples) - len(kept)
+	d.samples = kept
+	return removed
+}
+
+// Relabel changes the label of the sample at index i and of its augmented variants
+func (d *Dataset) Relabel(i int, label string) error {
+	if err := d.checkIndex(i); err != nil {
+		return err
+	}
+	if label == "" {
+		return fmt.Errorf("empty label")
+	}
+	id := d.samples[i].ID
+	for j := range d.samples {
+		if d.samples[j].ID == id || d.samples[j].Parent == id {
+			d.samples[j].Label = label
+		}
+	}
+	return nil
+}
+
+// Indices returns the indices of the samples with the given label
+// An empty label matches every sample
+func (d *Dataset) Indices(label string) []int {
+	indices := make([]int, 0)
+	for i, s := range d.samples {
+		if label == "" || s.Label == label {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// Len returns the number of samples in the dataset
+func (d *Dataset) Len() int {
+	return len(d.samples)
+}
+
+// Samples returns a copy of the samples in insertion order
+// Changing the copy does not change the dataset, which only changes through its
+// methods. The matrices are shared and must not be modified
+func (d *Dataset) Samples() []Sample {
+	return append(make([]Sample, 0, len(d.samples)), d.samples...)
+}
+
+// Labels returns the distinct labels in order of first appearance
+// This order is used for one-hot encoding and class indices. A subset lists
+// the labels of its parent dataset first, so class indices agree between them
+func (d *Dataset) Labels() []string {
+	seen := map[string]bool{}
+	labels := make([]string, 0)
+	for _, label := range d.classes {
+		seen[label] = true
+		labels = append(labels, label)
+	}
+	for _, s := range d.samples {
+		if !seen[s.Label] {
+			seen[s.Label] = true
+			labels = append(labels, s.Label)
+		}
+	}
+	return labels
+}
+
+// Subset returns a dataset holding the samples at the given indices in that order
+// The subset keeps the label order of d
+func (d *Dataset) Subset(indices []int) (*Dataset, error) {
+	subset := NewDataset(d.Rows, d.Cols)
+	subset.classes = d.Labels()
+	subset.lastID = d.lastID
+	for _, i := range indices {
+		if err := d.checkIndex(i); err != nil {
+			return nil, err
+		}
+		subset.samples = append(subset.samples, d.samples[i])
+	}
+	return subset, nil
+}
+
+// Clone returns a copy of the dataset that does not change with it
+// The matrices are shared and must not be modified
+func (d *Dataset) Clone() *Dataset {
+	clone := *d
+	clone.samples = d.Samples()
+	clone.classes = append([]string(nil), d.classes...)
+	return &clone
+}
+
+// Merge appends the samples of other with new IDs
+// Augmented variants stay linked to their originals
+func (d *Dataset) Merge(other *Dataset) error {
+	if other.Rows != d.Rows || other.Cols != d.Cols {
+		return fmt.Errorf("matrices are %dx%d, want %dx%d", other.Rows, other.Cols, d.Rows, d.Cols)
+	}
+	ids := make(map[uint64]uint64, other.Len())
+	for _, s := range other.samples {
+		ids[s.ID] = d.lastID + uint64(len(ids)) + 1
+	}
+	for _, s := range other.samples {
+		s.ID = ids[s.ID]
+		s.Parent = ids[s.Parent]
+		if err := d.AddSample(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset removes every sample from the dataset
+func (d *Dataset) Reset() {
+	d.samples = make([]Sample, 0)
+}
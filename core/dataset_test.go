@@ -0,0 +1,206 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testMatrix returns a rows x cols matrix with the cells set where the pattern has a '1'
+func testMatrix(pattern ...string) [][]int8 {
+	matrix := make([][]int8, len(pattern))
+	for y, line := range pattern {
+		matrix[y] = make([]int8, len(line))
+		for x, c := range line {
+			if c == '1' {
+				matrix[y][x] = 1
+			}
+		}
+	}
+	return matrix
+}
+
+// testDataset returns a 2x2 dataset with one sample per label, in order
+func testDataset(t *testing.T, labels ...string) *Dataset {
+	t.Helper()
+	d := NewDataset(2, 2)
+	for _, label := range labels {
+		if err := d.Add(testMatrix("10", "01"), label); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return d
+}
+
+// sampleLabels returns the labels of the samples of d in order
+func sampleLabels(d *Dataset) []string {
+	labels := make([]string, 0, d.Len())
+	for _, s := range d.Samples() {
+		labels = append(labels, s.Label)
+	}
+	return labels
+}
+
+func TestDatasetAddSample(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample Sample
+		ok     bool
+	}{
+		{"valid", Sample{Matrix: testMatrix("10", "01"), Label: "a"}, true},
+		{"with ink", Sample{Matrix: testMatrix("10", "01"), Ink: [][]uint8{{255, 0}, {0, 255}}, Label: "a"}, true},
+		{"empty label", Sample{Matrix: testMatrix("10", "01")}, false},
+		{"too few rows", Sample{Matrix: testMatrix("10"), Label: "a"}, false},
+		{"too many columns", Sample{Matrix: testMatrix("100", "010"), Label: "a"}, false},
+		{"ink size", Sample{Matrix: testMatrix("10", "01"), Ink: [][]uint8{{255}}, Label: "a"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDataset(2, 2)
+			err := d.AddSample(tt.sample)
+			if (err == nil) != tt.ok {
+				t.Fatalf("AddSample() error = %v, want ok %v", err, tt.ok)
+			}
+			if want := map[bool]int{true: 1, false: 0}[tt.ok]; d.Len() != want {
+				t.Errorf("Len() = %d, want %d", d.Len(), want)
+			}
+		})
+	}
+}
+
+func TestDatasetIDs(t *testing.T) {
+	d := testDataset(t, "a", "b")
+	if err := d.AddSample(Sample{Matrix: testMatrix("10", "01"), Label: "c", ID: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddSample(Sample{Matrix: testMatrix("10", "01"), Label: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	var ids []uint64
+	for _, s := range d.Samples() {
+		ids = append(ids, s.ID)
+	}
+	if want := []uint64{1, 2, 10, 11}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("IDs = %v, want %v", ids, want)
+	}
+	if err := d.AddSample(Sample{Matrix: testMatrix("10", "01"), Label: "e", ID: 2}); err == nil {
+		t.Error("AddSample() with a duplicate ID succeeded")
+	}
+}
+
+func TestDatasetVariantsFollowOriginal(t *testing.T) {
+	d := testDataset(t, "a", "b")
+	if err := d.AddSample(Sample{Matrix: testMatrix("11", "01"), Label: "a", Parent: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Relabel(0, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sampleLabels(d), []string{"x", "b", "x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("labels after Relabel = %v, want %v", got, want)
+	}
+	if err := d.Relabel(0, ""); err == nil {
+		t.Error("Relabel() to an empty label succeeded")
+	}
+
+	if err := d.Remove(0); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sampleLabels(d), []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("labels after Remove = %v, want %v", got, want)
+	}
+	if err := d.Remove(1); err == nil {
+		t.Error("Remove() out of range succeeded")
+	}
+}
+
+func TestDatasetRemoveAugmented(t *testing.T) {
+	d := testDataset(t, "a", "b")
+	for _, parent := range []uint64{1, 1, 2} {
+		if err := d.AddSample(Sample{Matrix: testMatrix("11", "01"), Label: "a", Parent: parent}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if removed := d.RemoveAugmented(); removed != 3 {
+		t.Errorf("RemoveAugmented() = %d, want 3", removed)
+	}
+	if d.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", d.Len())
+	}
+}
+
+func TestDatasetLabelsAndSubset(t *testing.T) {
+	d := testDataset(t, "b", "a", "b", "c")
+	if got, want := d.Labels(), []string{"b", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Labels() = %v, want %v", got, want)
+	}
+	if got, want := d.Indices("b"), []int{0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Indices(b) = %v, want %v", got, want)
+	}
+
+	subset, err := d.Subset([]int{3, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sampleLabels(subset), []string{"c", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("subset samples = %v, want %v", got, want)
+	}
+	// The subset keeps the class order of its parent
+	if got, want := subset.Labels(), []string{"b", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("subset Labels() = %v, want %v", got, want)
+	}
+	if _, err = d.Subset([]int{4}); err == nil {
+		t.Error("Subset() out of range succeeded")
+	}
+}
+
+func TestDatasetMerge(t *testing.T) {
+	d := testDataset(t, "a")
+	other := testDataset(t, "b")
+	if err := other.AddSample(Sample{Matrix: testMatrix("11", "01"), Label: "b", Parent: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	samples := d.Samples()
+	if len(samples) != 3 {
+		t.Fatalf("Len() = %d, want 3", len(samples))
+	}
+	if samples[1].ID != 2 || samples[2].ID != 3 || samples[2].Parent != 2 {
+		t.Errorf("merged IDs = %d, %d (parent %d), want 2, 3 (parent 2)", samples[1].ID, samples[2].ID, samples[2].Parent)
+	}
+	if err := d.Merge(NewDataset(3, 3)); err == nil {
+		t.Error("Merge() of another matrix size succeeded")
+	}
+}
+
+func TestDatasetSamplesIsCopy(t *testing.T) {
+	d := testDataset(t, "a")
+	samples := d.Samples()
+	samples[0].Label = "changed"
+	if s, _ := d.Sample(0); s.Label != "a" {
+		t.Errorf("Label = %q after changing the result of Samples(), want a", s.Label)
+	}
+}
+
+func TestDatasetClone(t *testing.T) {
+	d := testDataset(t, "a", "b")
+	clone := d.Clone()
+	if err := d.Relabel(0, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Add(testMatrix("11", "11"), "c"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sampleLabels(clone), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("clone labels = %v, want %v", got, want)
+	}
+	// New samples of the clone continue the IDs of the original
+	if err := clone.Add(testMatrix("11", "11"), "c"); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := clone.Sample(2); s.ID != 3 {
+		t.Errorf("ID of a sample added to the clone = %d, want 3", s.ID)
+	}
+}
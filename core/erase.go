@@ -0,0 +1,61 @@
+package core
+
+import "math"
+
+// Erase removes the parts of the strokes within radius of (x, y)
+// Strokes crossing the erased area are split into separate strokes.
+// It reports whether any stroke was changed.
+func Erase(strokes []Stroke, x, y, radius float32) ([]Stroke, bool) {
+	result := make([]Stroke, 0, len(strokes))
+	changed := false
+	for _, s := range strokes {
+		reach := float64(radius + s.Width/2)
+		piece := make([]Point, 0, len(s.Points))
+		flush := func() {
+			if len(piece) > 0 {
+				result = append(result, Stroke{Points: piece, Width: s.Width})
+				piece = make([]Point, 0)
+			}
+		}
+		for i, p := range s.Points {
+			if math.Hypot(float64(p.X-x), float64(p.Y-y)) <= reach {
+				changed = true
+				flush()
+				continue
+			}
+			if i > 0 && len(piece) > 0 {
+				prev := s.Points[i-1]
+				d := distanceToSegment(float64(x), float64(y), float64(prev.X), float64(prev.Y), float64(p.X), float64(p.Y))
+				if d <= reach {
+					changed = true
+					flush()
+				}
+			}
+			piece = append(piece, p)
+		}
+		flush()
+	}
+	if !changed {
+		return strokes, false
+	}
+	return result, true
+}
+
+// EraseAlong erases along the straight path from (x1, y1) to (x2, y2)
+// The path is sampled so fast eraser movements do not skip strokes.
+func EraseAlong(strokes []Stroke, x1, y1, x2, y2, radius float32) ([]Stroke, bool) {
+	step := radius / 2
+	if step < 1 {
+		step = 1
+	}
+	length := float32(math.Hypot(float64(x2-x1), float64(y2-y1)))
+	n := int(length/step) + 1
+	changed := false
+	for i := 0; i <= n; i++ {
+		t := float32(i) / float32(n)
+		var c bool
+		strokes, c = Erase(strokes, x1+(x2-x1)*t, y1+(y2-y1)*t, radius)
+		changed = changed || c
+	}
+	return strokes, changed
+}
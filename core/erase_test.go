@@ -0,0 +1,84 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testLine returns a horizontal stroke at y with points from x0 to x1 every step
+func testLine(x0, x1, step, y float32) Stroke {
+	s := Stroke{Width: 2}
+	for x := x0; x <= x1; x += step {
+		s.Points = append(s.Points, Point{X: x, Y: y})
+	}
+	return s
+}
+
+func TestErase(t *testing.T) {
+	tests := []struct {
+		name    string
+		strokes []Stroke
+		at      Point
+		want    []Stroke
+		changed bool
+	}{
+		{
+			name:    "miss",
+			strokes: []Stroke{testLine(0, 100, 10, 0)},
+			at:      Point{X: 50, Y: 50},
+			want:    []Stroke{testLine(0, 100, 10, 0)},
+		},
+		{
+			name:    "split at a point",
+			strokes: []Stroke{testLine(0, 100, 10, 0)},
+			at:      Point{X: 50, Y: 0},
+			want:    []Stroke{testLine(0, 40, 10, 0), testLine(60, 100, 10, 0)},
+			changed: true,
+		},
+		{
+			name:    "split between points",
+			strokes: []Stroke{testLine(0, 100, 100, 0)},
+			at:      Point{X: 50, Y: 0},
+			want:    []Stroke{testLine(0, 0, 1, 0), testLine(100, 100, 1, 0)},
+			changed: true,
+		},
+		{
+			name:    "remove dot",
+			strokes: []Stroke{testLine(5, 5, 1, 5), testLine(0, 100, 10, 50)},
+			at:      Point{X: 5, Y: 5},
+			want:    []Stroke{testLine(0, 100, 10, 50)},
+			changed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := Erase(tt.strokes, tt.at.X, tt.at.Y, 5)
+			if changed != tt.changed {
+				t.Errorf("changed = %v, want %v", changed, tt.changed)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Erase() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEraseAlong(t *testing.T) {
+	strokes := []Stroke{testLine(0, 100, 100, 0)}
+	// Neither end of a fast vertical eraser move touches the stroke
+	if _, changed := Erase(strokes, 30, -50, 2); changed {
+		t.Fatal("Erase() at the start of the path changed the stroke")
+	}
+	got, changed := EraseAlong(strokes, 30, -50, 30, 50, 2)
+	if !changed {
+		t.Fatal("EraseAlong() did not change the crossed stroke")
+	}
+	if want := []Stroke{testLine(0, 0, 1, 0), testLine(100, 100, 1, 0)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EraseAlong() = %v, want %v", got, want)
+	}
+
+	got, changed = EraseAlong(strokes, 0, 50, 100, 50, 2)
+	if changed || !reflect.DeepEqual(got, strokes) {
+		t.Errorf("EraseAlong() away from the stroke = %v, %v, want it unchanged", got, changed)
+	}
+}
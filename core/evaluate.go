@@ -0,0 +1,225 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// CrossValidation configures a k-fold cross-validation of the k-NN classifier
+// The originals of every label are spread evenly over the folds. Augmented
+// variants are only used for training, and never while their original is tested
+type CrossValidation struct {
+	Folds    int      // Number of folds, each original is tested once
+	K        int      // Number of neighbours of the classifier
+	Distance Distance // Distance of the classifier
+	Seed     int64    // Seed of the random fold assignment
+}
+
+// Validate returns an error when a setting is out of range
+func (cv CrossValidation) Validate() error {
+	if cv.Folds < 2 {
+		return fmt.Errorf("folds must be at least 2")
+	}
+	_, err := NewKNN(cv.K, cv.Distance)
+	return err
+}
+
+// Mistake is a misclassified sample paired with the training sample that misled it most
+type Mistake struct {
+	Index     int     // Dataset index of the misclassified sample
+	Neighbour int     // Dataset index of the nearest training sample with the predicted label
+	Actual    string  // Label of the sample
+	Predicted string  // Label the classifier predicted
+	Distance  float64 // Distance between the two samples
+}
+
+// ConfusedPair counts how often samples of one label were predicted as another
+type ConfusedPair struct {
+	Actual    string
+	Predicted string
+	Count     int
+}
+
+// Evaluation is the result of a cross-validation
+type Evaluation struct {
+	CrossValidation           // Settings the evaluation ran with
+	Labels          []string  // Label order of the confusion matrix
+	Confusion       [][]int   // Confusion[actual][predicted] counts the tested samples
+	Mistakes        []Mistake // Misclassified samples, the closest pairs first
+}
+
+// Run cross-validates the k-NN classifier on d
+// progress, when not nil, is called after each fold with the number of folds done
+func (cv CrossValidation) Run(d *Dataset, progress func(done int)) (*Evaluation, error) {
+	if err := cv.Validate(); err != nil {
+		return nil, err
+	}
+	labels := d.Labels()
+	index := make(map[string]int, len(labels))
+	for i, label := range labels {
+		index[label] = i
+	}
+
+	// Deal the originals of every label over the folds in random order
+	samples := d.Samples()
+	fold := make(map[uint64]int) // Fold of every original by sample ID
+	perLabel := make([][]int, len(labels))
+	for i, s := range samples {
+		if !s.Augmented() {
+			perLabel[index[s.Label]] = append(perLabel[index[s.Label]], i)
+		}
+	}
+	originals := 0
+	random := rand.New(rand.NewSource(cv.Seed))
+	next := 0
+	for _, indices := range perLabel {
+		random.Shuffle(len(indices), func(i, j int) {
+			indices[i], indices[j] = indices[j], indices[i]
+		})
+		for _, i := range indices {
+			fold[samples[i].ID] = next % cv.Folds
+			next++
+		}
+		originals += len(indices)
+	}
+	if originals < cv.Folds {
+		return nil, fmt.Errorf("%d original samples are too few for %d folds", originals, cv.Folds)
+	}
+
+	e := &Evaluation{CrossValidation: cv, Labels: labels, Confusion: make([][]int, len(labels))}
+	for i := range e.Confusion {
+		e.Confusion[i] = make([]int, len(labels))
+	}
+	for f := 0; f < cv.Folds; f++ {
+		train := make([]Sample, 0, len(samples))
+		trainIndex := make([]int, 0, len(samples)) // Dataset index of every training sample
+		test := make([]int, 0)
+		for i, s := range samples {
+			id := s.ID
+			if s.Augmented() {
+				id = s.Parent
+			}
+			if sampleFold, ok := fold[id]; ok && sampleFold == f {
+				if !s.Augmented() {
+					test = append(test, i)
+				}
+				continue
+			}
+			train = append(train, s)
+			trainIndex = append(trainIndex, i)
+		}
+
+		c, _ := NewKNN(cv.K, cv.Distance)
+		c.TrainSamples(labels, train)
+		for _, i := range test {
+			s := samples[i]
+			predictions := c.Predict(s, 1)
+			if len(predictions) == 0 {
+				continue
+			}
+			actual, predicted := index[s.Label], index[predictions[0].Label]
+			e.Confusion[actual][predicted]++
+			if actual != predicted {
+				e.Mistakes = append(e.Mistakes, c.mistake(s, predicted, trainIndex, i))
+			}
+		}
+		if progress != nil {
+			progress(f + 1)
+		}
+	}
+
+	sort.SliceStable(e.Mistakes, func(i, j int) bool {
+		return e.Mistakes[i].Distance < e.Mistakes[j].Distance
+	})
+	return e, nil
+}
+
+// mistake pairs the misclassified sample s at dataset index i with its nearest training
+// sample of the predicted label. trainIndex maps training samples to dataset indices
+func (c *KNN) mistake(s Sample, predicted int, trainIndex []int, i int) Mistake {
+	query := c.prepare(s)
+	m := Mistake{Index: i, Neighbour: -1, Actual: s.Label, Predicted: c.labels[predicted], Distance: math.Inf(1)}
+	for j := range c.samples {
+		if c.samples[j].label != predicted {
+			continue
+		}
+		if d := c.distance(&query, &c.samples[j]); d < m.Distance {
+			m.Distance = d
+			m.Neighbour = trainIndex[j]
+		}
+	}
+	if c.Distance == EuclideanDistance {
+		m.Distance = math.Sqrt(m.Distance)
+	}
+	return m
+}
+
+// Total returns the number of tested samples
+func (e *Evaluation) Total() int {
+	total := 0
+	for _, row := range e.Confusion {
+		for _, n := range row {
+			total += n
+		}
+	}
+	return total
+}
+
+// Accuracy returns the share of the tested samples that were classified correctly
+func (e *Evaluation) Accuracy() float64 {
+	correct := 0
+	for i := range e.Confusion {
+		correct += e.Confusion[i][i]
+	}
+	return ratio(correct, e.Total())
+}
+
+// Support returns the number of tested samples of label i
+func (e *Evaluation) Support(i int) int {
+	total := 0
+	for _, n := range e.Confusion[i] {
+		total += n
+	}
+	return total
+}
+
+// Recall returns the share of the samples of label i that were classified as i,
+// which is the accuracy of that label
+func (e *Evaluation) Recall(i int) float64 {
+	return ratio(e.Confusion[i][i], e.Support(i))
+}
+
+// Precision returns the share of the samples classified as label i that carry it
+func (e *Evaluation) Precision(i int) float64 {
+	predicted := 0
+	for _, row := range e.Confusion {
+		predicted += row[i]
+	}
+	return ratio(e.Confusion[i][i], predicted)
+}
+
+// ConfusedPairs returns the label pairs that were confused, the most frequent first
+func (e *Evaluation) ConfusedPairs() []ConfusedPair {
+	pairs := make([]ConfusedPair, 0)
+	for actual, row := range e.Confusion {
+		for predicted, n := range row {
+			if actual != predicted && n > 0 {
+				pairs = append(pairs, ConfusedPair{Actual: e.Labels[actual], Predicted: e.Labels[predicted], Count: n})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Count > pairs[j].Count
+	})
+	return pairs
+}
+
+// ratio returns a / b, or 0 when b is 0
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
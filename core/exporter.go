@@ -0,0 +1,123 @@
+package core
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Exporter writes a dataset into one or more files inside a directory
+type Exporter interface {
+	// Export writes the dataset into dirPath
+	Export(dirPath string, d *Dataset) error
+	// Files returns the paths Export writes inside dirPath
+	Files(dirPath string) []string
+}
+
+// CSVExporter writes the dataset as a CSV file with an Input,Target header
+type CSVExporter struct {
+	FileName string // File name without extension
+	Flat     bool   // Whether to flatten each matrix into a single row
+	Values   Values // Cell values to write
+}
+
+// Files implements Exporter
+func (e CSVExporter) Files(dirPath string) []string {
+	return []string{filepath.Join(dirPath, e.FileName+".csv")}
+}
+
+// Export implements Exporter
+func (e CSVExporter) Export(dirPath string, d *Dataset) error {
+	path := e.Files(dirPath)[0]
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err = file.WriteString("Input,Target\n"); err != nil {
+		return err
+	}
+
+	csvWriter := csv.NewWriter(file)
+	csvWriter.UseCRLF = true
+	for _, s := range d.Samples() {
+		values := e.Values.Matrix(s)
+		var dataString string
+		if e.Flat {
+			dataString = FlattenString(values, RowFlat)
+		} else {
+			dataString = fmt.Sprintf("%v", values)
+		}
+		if err = csvWriter.Write([]string{dataString, s.Label}); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// MatlabExporter writes the dataset as MATLAB compatible text files
+// The data file holds one flattened sample per column, the target file
+// holds either the label list or a one-hot encoded matrix
+type MatlabExporter struct {
+	DataFileName   string // Data file name without extension
+	TargetFileName string // Target file name without extension
+	DotMFile       bool   // Whether to write .m files that assign a variable
+	OneHot         bool   // Whether to one-hot encode the targets
+	Values         Values // Cell values to write
+}
+
+// Files implements Exporter
+func (e MatlabExporter) Files(dirPath string) []string {
+	extension := ".txt"
+	if e.DotMFile {
+		extension = ".m"
+	}
+	return []string{
+		filepath.Join(dirPath, e.DataFileName+extension),
+		filepath.Join(dirPath, e.TargetFileName+extension),
+	}
+}
+
+// Export implements Exporter
+func (e MatlabExporter) Export(dirPath string, d *Dataset) error {
+	files := e.Files(dirPath)
+	dataPath, targetPath := files[0], files[1]
+
+	samples := d.Samples()
+	flat := make([][]float64, len(samples))
+	targets := make([]string, len(samples))
+	for i, s := range samples {
+		flat[i] = Flatten(e.Values.Matrix(s))
+		targets[i] = s.Label
+	}
+
+	finalData := MatlabString(Transpose(flat))
+	if e.DotMFile {
+		finalData = e.DataFileName + "_variable = " + finalData + ";"
+	}
+	if err := os.WriteFile(dataPath, []byte(finalData), 0600); err != nil {
+		return err
+	}
+
+	var finalTarget string
+	if e.OneHot {
+		classes := d.Labels()
+		encoded := make([][]int8, len(targets))
+		for i, label := range targets {
+			encoded[i] = OneHot(label, classes)
+		}
+		finalTarget = MatlabString(Transpose(encoded))
+		if e.DotMFile {
+			finalTarget = e.TargetFileName + "_variable = " + finalTarget + ";"
+		}
+	} else {
+		finalTarget = fmt.Sprintf("%v", targets)
+		if e.DotMFile {
+			finalTarget = e.TargetFileName + " = " + finalTarget + ";"
+		}
+	}
+	return os.WriteFile(targetPath, []byte(finalTarget), 0600)
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"os"
+	"testing"
+)
+
+// readTestFile returns the content of path or fails the test
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCSVExporter(t *testing.T) {
+	tests := []struct {
+		name string
+		flat bool
+		want string
+	}{
+		{"matrix", false, "Input,Target\n[[1 0] [0 1]],a\r\n[[1 0] [0 1]],b\r\n"},
+		{"flat", true, "Input,Target\n[1 0 0 1],a\r\n[1 0 0 1],b\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			e := CSVExporter{FileName: "data", Flat: tt.flat}
+			if err := e.Export(dir, testDataset(t, "a", "b")); err != nil {
+				t.Fatal(err)
+			}
+			if got := readTestFile(t, e.Files(dir)[0]); got != tt.want {
+				t.Errorf("data.csv = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatlabExporter(t *testing.T) {
+	tests := []struct {
+		name       string
+		exporter   MatlabExporter
+		wantData   string
+		wantTarget string
+	}{
+		{
+			name:       "text",
+			exporter:   MatlabExporter{DataFileName: "data", TargetFileName: "target"},
+			wantData:   "[ 1 1 ;\n0 0 ;\n0 0 ;\n1 1 ]",
+			wantTarget: "[a b]",
+		},
+		{
+			name:       "one-hot m-file",
+			exporter:   MatlabExporter{DataFileName: "data", TargetFileName: "target", DotMFile: true, OneHot: true},
+			wantData:   "data_variable = [ 1 1 ;\n0 0 ;\n0 0 ;\n1 1 ];",
+			wantTarget: "target_variable = [ 1 0 ;\n0 1 ];",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := tt.exporter.Export(dir, testDataset(t, "a", "b")); err != nil {
+				t.Fatal(err)
+			}
+			files := tt.exporter.Files(dir)
+			if got := readTestFile(t, files[0]); got != tt.wantData {
+				t.Errorf("data = %q, want %q", got, tt.wantData)
+			}
+			if got := readTestFile(t, files[1]); got != tt.wantTarget {
+				t.Errorf("target = %q, want %q", got, tt.wantTarget)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format names accepted by NewExporter
+const (
+	FormatCSV    = "csv"    // CSV file with Input,Target columns
+	FormatMatlab = "matlab" // MATLAB text matrices (.txt or .m)
+	FormatMat    = "mat"    // Binary MAT-file
+	FormatNpy    = "npy"    // Separate NumPy arrays
+	FormatNpz    = "npz"    // NumPy arrays bundled in one archive
+	FormatIDX    = "idx"    // MNIST IDX files
+)
+
+// Formats lists every format name accepted by NewExporter
+var Formats = []string{FormatCSV, FormatMatlab, FormatMat, FormatNpy, FormatNpz, FormatIDX}
+
+// ExportSettings holds the options shared by the exporters
+// Each exporter uses the options that apply to its format
+type ExportSettings struct {
+	DataFileName   string // Data file name without extension
+	TargetFileName string // Target file name without extension
+	Flat           bool   // Flatten matrices (CSV, NumPy)
+	OneHot         bool   // One-hot encode targets (MATLAB, MAT-file)
+	DotMFile       bool   // Write .m files assigning variables (MATLAB)
+	Compress       bool   // Compress variables (MAT-file)
+	Values         Values // Cell values written by every format
+	Split          Split  // Sample selection, shuffle and train/val/test split applied before writing
+}
+
+// NewExporter returns the exporter for a format name
+func NewExporter(format string, s ExportSettings) (Exporter, error) {
+	if err := s.Values.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.Split.Validate(); err != nil {
+		return nil, err
+	}
+	if s.Split.Active() {
+		return NewSplitExporter(format, s)
+	}
+	name := strings.ToLower(format)
+	switch name {
+	case FormatCSV:
+		return CSVExporter{FileName: s.DataFileName, Flat: s.Flat, Values: s.Values}, nil
+	case FormatMatlab:
+		return MatlabExporter{DataFileName: s.DataFileName, TargetFileName: s.TargetFileName, DotMFile: s.DotMFile, OneHot: s.OneHot, Values: s.Values}, nil
+	case FormatMat:
+		return MatExporter{DataFileName: s.DataFileName, TargetFileName: s.TargetFileName, OneHot: s.OneHot, Compress: s.Compress, Values: s.Values}, nil
+	case FormatNpy, FormatNpz:
+		return NpyExporter{DataFileName: s.DataFileName, TargetFileName: s.TargetFileName, Flat: s.Flat, Bundle: name == FormatNpz, Values: s.Values}, nil
+	case FormatIDX:
+		return IDXExporter{ImagesFileName: s.DataFileName, LabelsFileName: s.TargetFileName, Values: s.Values}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
@@ -0,0 +1,265 @@
+package core
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// IDX type codes
+const (
+	idxUbyte = 0x08 // Unsigned bytes
+	idxFloat = 0x0D // Big-endian 32 bit floats
+)
+
+// IDXExporter writes the dataset in the MNIST IDX format
+// Images go to an idx3-ubyte file as intensities with full ink as 255, or as
+// floats from 0.0 to 1.0 for UnitValues, labels to an idx1-ubyte file. Labels that are all numbers from 0 to 255 are written as is, other
+// labels are written as class indices and their names are listed one per
+// line in a classes file.
+type IDXExporter struct {
+	ImagesFileName string // Images file name without the -idx3-ubyte suffix
+	LabelsFileName string // Labels file name without the -idx1-ubyte suffix
+	Values         Values // Cell values to write
+}
+
+// IDXClassesPath returns the classes file written next to an idx1-ubyte labels file
+func IDXClassesPath(labelsPath string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(labelsPath, ".gz"), "-idx1-ubyte")
+	return base + "-classes.txt"
+}
+
+// Files implements Exporter
+// The classes file is only written when labels are not numeric
+func (e IDXExporter) Files(dirPath string) []string {
+	labelsPath := filepath.Join(dirPath, e.LabelsFileName+"-idx1-ubyte")
+	return []string{
+		filepath.Join(dirPath, e.ImagesFileName+"-idx3-ubyte"),
+		labelsPath,
+		IDXClassesPath(labelsPath),
+	}
+}
+
+// Export implements Exporter
+func (e IDXExporter) Export(dirPath string, d *Dataset) error {
+	files := e.Files(dirPath)
+	samples := d.Samples()
+	classes := d.Labels()
+	numeric := numericLabels(classes)
+	if !numeric && len(classes) > 256 {
+		return fmt.Errorf("IDX labels support at most 256 classes, got %d", len(classes))
+	}
+
+	imageType := byte(idxUbyte)
+	if !e.Values.Integer() {
+		imageType = idxFloat
+	}
+	images := make([]byte, 0, len(samples)*d.Rows*d.Cols)
+	for _, s := range samples {
+		for _, v := range Flatten(e.Values.Matrix(s)) {
+			if imageType == idxFloat {
+				images = binary.BigEndian.AppendUint32(images, math.Float32bits(float32(v)))
+			} else {
+				// Spread binary cells and levels over the 0 to 255 intensity range
+				images = append(images, byte(math.Round(v*255/e.Values.Max())))
+			}
+		}
+	}
+	if err := writeIDXFile(files[0], imageType, []int{len(samples), d.Rows, d.Cols}, images); err != nil {
+		return err
+	}
+
+	index := make(map[string]int, len(classes))
+	for i, label := range classes {
+		index[label] = i
+	}
+	labels := make([]byte, len(samples))
+	for i, s := range samples {
+		if numeric {
+			v, _ := strconv.Atoi(s.Label)
+			labels[i] = byte(v)
+		} else {
+			labels[i] = byte(index[s.Label])
+		}
+	}
+	if err := writeIDXFile(files[1], idxUbyte, []int{len(samples)}, labels); err != nil {
+		return err
+	}
+
+	if numeric {
+		// A classes file left from an earlier export would rename the labels on import
+		if err := os.Remove(files[2]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(files[2], []byte(strings.Join(classes, "\n")+"\n"), 0600)
+}
+
+// numericLabels reports whether every label is a number from 0 to 255
+func numericLabels(labels []string) bool {
+	for _, label := range labels {
+		v, err := strconv.Atoi(label)
+		if err != nil || v < 0 || v > 255 || strconv.Itoa(v) != label {
+			return false
+		}
+	}
+	return true
+}
+
+// writeIDXFile writes an IDX file of the given type code and dimensions
+func writeIDXFile(path string, dataType byte, dims []int, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	header := []byte{0, 0, dataType, byte(len(dims))}
+	for _, n := range dims {
+		header = binary.BigEndian.AppendUint32(header, uint32(n))
+	}
+	if _, err = w.Write(header); err != nil {
+		return err
+	}
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// ReadIDX reads an unsigned byte or float IDX file and returns its dimensions and data
+// Floats from 0.0 to 1.0, as written for UnitValues, are scaled to bytes from 0 to 255.
+// Files ending in .gz are decompressed on the fly
+func ReadIDX(path string) ([]int, []byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	compressed := strings.HasSuffix(path, ".gz")
+	var r io.Reader = bufio.NewReader(file)
+	if compressed {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	magic := make([]byte, 4)
+	if _, err = io.ReadFull(r, magic); err != nil {
+		return nil, nil, err
+	}
+	if magic[0] != 0 || magic[1] != 0 || (magic[2] != idxUbyte && magic[2] != idxFloat) {
+		return nil, nil, fmt.Errorf("%s: not an unsigned byte or float IDX file", filepath.Base(path))
+	}
+	width := 1
+	if magic[2] == idxFloat {
+		width = 4
+	}
+	dims := make([]int, magic[3])
+	size := 1
+	for i := range dims {
+		var n uint32
+		if err = binary.Read(r, binary.BigEndian, &n); err != nil {
+			return nil, nil, err
+		}
+		dims[i] = int(n)
+		if dims[i] != 0 && size > math.MaxInt/width/dims[i] {
+			return nil, nil, fmt.Errorf("%s: dimensions are too large", filepath.Base(path))
+		}
+		size *= dims[i]
+	}
+
+	// The header is not trusted with the allocation: an uncompressed file must
+	// hold all the data, a compressed one is read only as far as it goes
+	if !compressed {
+		info, err := file.Stat()
+		if err != nil {
+			return nil, nil, err
+		}
+		if remaining := info.Size() - int64(4+4*len(dims)); remaining < int64(size*width) {
+			return nil, nil, fmt.Errorf("%s: %d bytes of data, want %d", filepath.Base(path), remaining, size*width)
+		}
+	}
+	raw, err := io.ReadAll(io.LimitReader(r, int64(size*width)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(raw) < size*width {
+		return nil, nil, fmt.Errorf("%s: %d bytes of data, want %d", filepath.Base(path), len(raw), size*width)
+	}
+	if magic[2] == idxUbyte {
+		return dims, raw, nil
+	}
+	data := make([]byte, size)
+	for i := range data {
+		v := float64(math.Float32frombits(binary.BigEndian.Uint32(raw[i*4:])))
+		data[i] = byte(math.Round(math.Max(0, math.Min(1, v)) * 255))
+	}
+	return dims, data, nil
+}
+
+// LoadIDX reads an idx3-ubyte (or float) images file and its idx1-ubyte labels file
+// Pixels of 128 and above become 1, the pixels are kept as ink intensities. When a classes file written by
+// IDXExporter sits next to the labels, labels are mapped back to its names,
+// otherwise the numeric labels are used.
+func LoadIDX(imagesPath, labelsPath string) (*Dataset, error) {
+	dims, images, err := ReadIDX(imagesPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(dims) != 3 {
+		return nil, fmt.Errorf("images file has %d dimensions, want 3", len(dims))
+	}
+	labelDims, labels, err := ReadIDX(labelsPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(labelDims) != 1 || labelDims[0] != dims[0] {
+		return nil, fmt.Errorf("labels file does not match %d images", dims[0])
+	}
+
+	var classes []string
+	if content, err := os.ReadFile(IDXClassesPath(labelsPath)); err == nil {
+		classes = strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	}
+
+	n, rows, cols := dims[0], dims[1], dims[2]
+	d := NewDataset(rows, cols)
+	for i := 0; i < n; i++ {
+		matrix := make([][]int8, rows)
+		ink := make([][]uint8, rows)
+		for y := range matrix {
+			matrix[y] = make([]int8, cols)
+			ink[y] = images[(i*rows+y)*cols : (i*rows+y+1)*cols]
+			for x, v := range ink[y] {
+				if v >= 128 {
+					matrix[y][x] = 1
+				}
+			}
+		}
+		label := strconv.Itoa(int(labels[i]))
+		if classes != nil {
+			if int(labels[i]) >= len(classes) {
+				return nil, fmt.Errorf("label %d has no class name", labels[i])
+			}
+			label = classes[labels[i]]
+		}
+		if err = d.AddSample(Sample{Matrix: matrix, Ink: ink, Label: label}); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
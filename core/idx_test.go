@@ -0,0 +1,135 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIDXExporterHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   Values
+		dataType byte
+		width    int
+	}{
+		{"binary", Values{}, idxUbyte, 1},
+		{"unit", Values{Mode: UnitValues}, idxFloat, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			e := IDXExporter{ImagesFileName: "images", LabelsFileName: "labels", Values: tt.values}
+			if err := e.Export(dir, testDataset(t, "a", "b", "a")); err != nil {
+				t.Fatal(err)
+			}
+			files := e.Files(dir)
+
+			images := []byte(readTestFile(t, files[0]))
+			want := []byte{0, 0, tt.dataType, 3, 0, 0, 0, 3, 0, 0, 0, 2, 0, 0, 0, 2}
+			if !bytes.HasPrefix(images, want) {
+				t.Errorf("images header = % x, want % x", images[:min(len(images), 16)], want)
+			}
+			if len(images) != 16+3*4*tt.width {
+				t.Errorf("images file has %d bytes, want %d", len(images), 16+3*4*tt.width)
+			}
+
+			labels := []byte(readTestFile(t, files[1]))
+			if want := []byte{0, 0, idxUbyte, 1, 0, 0, 0, 3, 0, 1, 0}; !bytes.Equal(labels, want) {
+				t.Errorf("labels file = % x, want % x", labels, want)
+			}
+			if classes := readTestFile(t, files[2]); classes != "a\nb\n" {
+				t.Errorf("classes file = %q, want a and b", classes)
+			}
+		})
+	}
+}
+
+func TestLoadIDX(t *testing.T) {
+	for _, values := range []Values{{}, {Mode: UnitValues}} {
+		dir := t.TempDir()
+		e := IDXExporter{ImagesFileName: "images", LabelsFileName: "labels", Values: values}
+		if err := e.Export(dir, testDataset(t, "a", "b")); err != nil {
+			t.Fatal(err)
+		}
+		files := e.Files(dir)
+		d, err := LoadIDX(files[0], files[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := sampleLabels(d), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("mode %d labels = %v, want %v", values.Mode, got, want)
+		}
+		s, _ := d.Sample(0)
+		if want := testMatrix("10", "01"); !reflect.DeepEqual(s.Matrix, want) {
+			t.Errorf("mode %d matrix = %v, want %v", values.Mode, s.Matrix, want)
+		}
+		if want := [][]uint8{{255, 0}, {0, 255}}; !reflect.DeepEqual(s.Ink, want) {
+			t.Errorf("mode %d ink = %v, want %v", values.Mode, s.Ink, want)
+		}
+	}
+}
+
+func TestLoadIDXNumericLabels(t *testing.T) {
+	dir := t.TempDir()
+	e := IDXExporter{ImagesFileName: "images", LabelsFileName: "labels"}
+	// An old classes file must not rename numeric labels
+	if err := e.Export(dir, testDataset(t, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Export(dir, testDataset(t, "7", "0")); err != nil {
+		t.Fatal(err)
+	}
+	files := e.Files(dir)
+	d, err := LoadIDX(files[0], files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sampleLabels(d), []string{"7", "0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("labels = %v, want %v", got, want)
+	}
+}
+
+// idxHeader returns the header of an IDX file with the given type code and dimensions
+func idxHeader(dataType byte, dims ...uint32) []byte {
+	header := []byte{0, 0, dataType, byte(len(dims))}
+	for _, n := range dims {
+		header = binary.BigEndian.AppendUint32(header, n)
+	}
+	return header
+}
+
+func TestReadIDXRejects(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(append(idxHeader(idxUbyte, 1<<20, 1<<10), 1, 2, 3))
+	zw.Close()
+
+	tests := []struct {
+		name    string
+		file    string
+		content []byte
+	}{
+		{"signed bytes", "data", append(idxHeader(0x09, 2), 1, 2)},
+		{"short header", "data", idxHeader(idxUbyte, 2)[:6]},
+		{"truncated", "data", append(idxHeader(idxUbyte, 2, 2), 1, 2, 3)},
+		{"truncated floats", "data", append(idxHeader(idxFloat, 1), 0, 0)},
+		{"huge dimensions", "data", append(idxHeader(idxUbyte, 1<<31, 1<<31, 1<<31, 1<<31), 1)},
+		{"huge compressed", "data.gz", gz.Bytes()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, tt.content, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if dims, _, err := ReadIDX(path); err == nil {
+				t.Errorf("ReadIDX() = %v, want an error", dims)
+			}
+		})
+	}
+}
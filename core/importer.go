@@ -0,0 +1,93 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ImageImporter converts image files into labelled samples
+// Images go through the same rasterizer as drawings, so imported and drawn
+// samples share one format.
+type ImageImporter struct {
+	Rasterizer *Rasterizer
+	// Pattern takes the label from the file name without extension: the first
+	// capture group, or the whole match when it has none. When nil the label
+	// is the name of the folder containing the image.
+	Pattern *regexp.Regexp
+	// FixedLabel, when not empty, labels every image and Pattern is not used
+	FixedLabel string
+}
+
+// FindImages returns every PNG, JPEG and GIF file below root in lexical order
+func FindImages(root string) ([]string, error) {
+	paths := make([]string, 0)
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && IsImageFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// Label returns the label of an image file
+func (im ImageImporter) Label(path string) (string, error) {
+	if im.FixedLabel != "" {
+		return im.FixedLabel, nil
+	}
+	if im.Pattern == nil {
+		return filepath.Base(filepath.Dir(path)), nil
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	match := im.Pattern.FindStringSubmatch(name)
+	if match == nil {
+		return "", fmt.Errorf("file name does not match %s", im.Pattern)
+	}
+	if len(match) > 1 {
+		return match[1], nil
+	}
+	return match[0], nil
+}
+
+// Sample loads one image file and converts it to a labelled sample
+func (im ImageImporter) Sample(path string) (Sample, error) {
+	label, err := im.Label(path)
+	if err != nil {
+		return Sample{}, err
+	}
+	if label == "" {
+		return Sample{}, fmt.Errorf("empty label")
+	}
+	img, err := LoadImage(path)
+	if err != nil {
+		return Sample{}, err
+	}
+	return im.Rasterizer.Sample(img, label), nil
+}
+
+// Import converts every given image file into a dataset
+// Files that cannot be read or labelled are skipped and returned with their errors.
+// progress, when not nil, is called after each file with the number of files done.
+func (im ImageImporter) Import(paths []string, progress func(done int)) (*Dataset, map[string]error) {
+	d := NewDataset(im.Rasterizer.Rows, im.Rasterizer.Cols)
+	failed := make(map[string]error)
+	for i, path := range paths {
+		sample, err := im.Sample(path)
+		if err == nil {
+			err = d.AddSample(sample)
+		}
+		if err != nil {
+			failed[path] = err
+		}
+		if progress != nil {
+			progress(i + 1)
+		}
+	}
+	return d, failed
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestImageImporterLabel(t *testing.T) {
+	path := filepath.Join("images", "seven", "x_7.png")
+	tests := []struct {
+		name     string
+		importer ImageImporter
+		want     string
+		ok       bool
+	}{
+		{"folder", ImageImporter{}, "seven", true},
+		{"capture group", ImageImporter{Pattern: regexp.MustCompile(`_(\d)$`)}, "7", true},
+		{"whole match", ImageImporter{Pattern: regexp.MustCompile(`\d`)}, "7", true},
+		{"no match", ImageImporter{Pattern: regexp.MustCompile(`^y`)}, "", false},
+		{"fixed", ImageImporter{FixedLabel: "z"}, "z", true},
+		{"fixed before pattern", ImageImporter{FixedLabel: "z", Pattern: regexp.MustCompile(`^y`)}, "z", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.importer.Label(path)
+			if (err == nil) != tt.ok || got != tt.want {
+				t.Errorf("Label() = %q, %v, want %q, ok %v", got, err, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestImageImporterImport(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a/1.png", "b/2.png", "b/broken.png"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Base(path) != "broken.png" {
+			err = png.Encode(file, testImage(20, 20, image.Rect(0, 0, 10, 10)))
+		}
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	found, err := FindImages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(found, paths) {
+		t.Errorf("FindImages() = %v, want %v", found, paths)
+	}
+
+	d, failed := ImageImporter{Rasterizer: NewRasterizer(2, 2)}.Import(paths, nil)
+	if got, want := sampleLabels(d), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("labels = %v, want %v", got, want)
+	}
+	if _, ok := failed[paths[2]]; !ok || len(failed) != 1 {
+		t.Errorf("failed = %v, want only %s", failed, paths[2])
+	}
+}
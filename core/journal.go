@@ -0,0 +1,111 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// journalRecord is the layout of a line of a journal file
+// It is a sample of samples.jsonl with its drawing inline
+type journalRecord struct {
+	projectSample
+	Drawing *projectDrawing `json:"drawing,omitempty"`
+}
+
+// Journal appends samples to a JSON lines file as soon as they are collected
+// Together with a project snapshot it allows recovering the samples collected
+// after the snapshot was written. It only records added samples, so the snapshot
+// must be rewritten, which truncates the journal, after any other change
+type Journal struct {
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// OpenJournal opens the journal at path for appending, creating it if needed
+func OpenJournal(path string) (*Journal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{file: f, encoder: json.NewEncoder(f)}, nil
+}
+
+// Append writes s to the journal and flushes it to disk
+func (j *Journal) Append(s Sample) error {
+	record := journalRecord{projectSample: newProjectSample(s)}
+	if s.Drawing != nil {
+		drawing := newProjectDrawing(s.ID, *s.Drawing)
+		record.Drawing = &drawing
+	}
+	if err := j.encoder.Encode(record); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// Truncate removes every sample from the journal, after they were saved elsewhere
+func (j *Journal) Truncate() error {
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := j.file.Seek(0, io.SeekStart)
+	return err
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+// errJournalEnd stops replaying a journal at a line cut off by a crash
+var errJournalEnd = errors.New("journal ends")
+
+// ReplayJournal adds the samples of the journal at path to d and returns how many were added
+// Samples whose ID is already in d are skipped, a missing journal has no samples
+// and a line that is not valid JSON, as left by a crash while writing, ends the journal
+func ReplayJournal(path string, d *Dataset) (int, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	known := make(map[uint64]bool, d.Len())
+	for _, s := range d.Samples() {
+		known[s.ID] = true
+	}
+	added := 0
+	err = scanJSONLines(f, path, func(decode func(interface{}) error) error {
+		var record journalRecord
+		if err := decode(&record); err != nil {
+			return errJournalEnd
+		}
+		if known[record.ID] {
+			return nil
+		}
+		var drawing *Drawing
+		if record.Drawing != nil {
+			drawing = record.Drawing.drawing()
+		}
+		s, err := record.sample(drawing)
+		if err != nil {
+			return err
+		}
+		if err = d.AddSample(s); err != nil {
+			return err
+		}
+		known[s.ID] = true
+		added++
+		return nil
+	})
+	if errors.Is(err, errJournalEnd) {
+		err = nil
+	}
+	return added, err
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJournalReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	samples := testProjectDataset(t).Samples()
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	for _, s := range samples {
+		if err = j.Append(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The snapshot already holds the first sample
+	d := NewDataset(2, 2)
+	if err = d.AddSample(samples[0]); err != nil {
+		t.Fatal(err)
+	}
+	added, err := ReplayJournal(path, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 2 || !reflect.DeepEqual(d.Samples(), samples) {
+		t.Errorf("ReplayJournal() added %d, samples %+v, want 2 and %+v", added, d.Samples(), samples)
+	}
+	if added, err = ReplayJournal(path, d); added != 0 || err != nil {
+		t.Errorf("second ReplayJournal() = %d, %v, want 0 and no error", added, err)
+	}
+
+	if err = j.Truncate(); err != nil {
+		t.Fatal(err)
+	}
+	if err = j.Append(samples[2]); err != nil {
+		t.Fatal(err)
+	}
+	d = NewDataset(2, 2)
+	if added, err = ReplayJournal(path, d); added != 1 || err != nil {
+		t.Errorf("ReplayJournal() after Truncate = %d, %v, want 1 and no error", added, err)
+	}
+}
+
+func TestReplayJournalCutOff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := Sample{ID: 1, Matrix: testMatrix("10", "01"), Label: "a"}
+	if err = j.Append(sample); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+	// A crash while writing leaves half a line
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"id": 2, "label": "b", "matr`)
+	f.Close()
+
+	d := NewDataset(2, 2)
+	added, err := ReplayJournal(path, d)
+	if err != nil || added != 1 {
+		t.Errorf("ReplayJournal() = %d, %v, want 1 and no error", added, err)
+	}
+
+	if added, err = ReplayJournal(filepath.Join(t.TempDir(), "missing.jsonl"), d); added != 0 || err != nil {
+		t.Errorf("ReplayJournal() of a missing journal = %d, %v, want 0 and no error", added, err)
+	}
+}
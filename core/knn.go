@@ -0,0 +1,218 @@
+package core
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// Distance selects how the k-NN classifier compares two samples
+type Distance int8
+
+const (
+	// HammingDistance counts the cells that differ between the binary matrices
+	HammingDistance Distance = iota
+	// EuclideanDistance is the euclidean distance between the ink intensities
+	// from 0 to 1, samples without ink intensities use their binary matrix
+	EuclideanDistance
+)
+
+// DistanceNames names the distances in the order of their values
+var DistanceNames = []string{"hamming", "euclidean"}
+
+// ParseDistance returns the distance with the given name
+func ParseDistance(name string) (Distance, error) {
+	for i, n := range DistanceNames {
+		if n == name {
+			return Distance(i), nil
+		}
+	}
+	return HammingDistance, fmt.Errorf("unknown distance %q", name)
+}
+
+// AmbiguityRatio is the share of the votes of the best label that the second
+// best label must reach for a prediction to count as ambiguous
+const AmbiguityRatio = 2.0 / 3
+
+// Prediction is a label with the share of the nearest neighbours that carry it
+type Prediction struct {
+	Label      string
+	Confidence float64 // From 0 to 1
+}
+
+// Ambiguous reports whether the best two predictions are too close to tell apart
+// The predictions must be sorted by confidence, as returned by KNN.Predict
+func Ambiguous(predictions []Prediction) bool {
+	return len(predictions) > 1 && predictions[1].Confidence >= predictions[0].Confidence*AmbiguityRatio
+}
+
+// knnSample is a training sample prepared for fast distance computation
+type knnSample struct {
+	label  int
+	bits   []uint64  // Binary matrix packed 64 cells per word
+	vector []float32 // Ink intensities from 0 to 1
+}
+
+// KNN is a k-nearest neighbours classifier on the samples of a dataset
+type KNN struct {
+	K        int
+	Distance Distance
+	labels   []string
+	samples  []knnSample
+}
+
+// NewKNN creates an untrained classifier
+func NewKNN(k int, distance Distance) (*KNN, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("k must be at least 1")
+	}
+	if distance != HammingDistance && distance != EuclideanDistance {
+		return nil, fmt.Errorf("unknown distance %d", distance)
+	}
+	return &KNN{K: k, Distance: distance}, nil
+}
+
+// Train replaces the training samples with the samples of d
+func (c *KNN) Train(d *Dataset) {
+	c.TrainSamples(d.Labels(), d.Samples())
+}
+
+// TrainSamples replaces the training samples
+// labels gives the label order, labels of samples missing from it are appended
+func (c *KNN) TrainSamples(labels []string, samples []Sample) {
+	c.labels = append([]string(nil), labels...)
+	index := make(map[string]int, len(labels))
+	for i, label := range c.labels {
+		index[label] = i
+	}
+	c.samples = make([]knnSample, len(samples))
+	for i, s := range samples {
+		label, ok := index[s.Label]
+		if !ok {
+			label = len(c.labels)
+			index[s.Label] = label
+			c.labels = append(c.labels, s.Label)
+		}
+		c.samples[i] = c.prepare(s)
+		c.samples[i].label = label
+	}
+}
+
+// Len returns the number of training samples
+func (c *KNN) Len() int {
+	return len(c.samples)
+}
+
+// prepare converts a sample for the distance of the classifier
+func (c *KNN) prepare(s Sample) knnSample {
+	var prepared knnSample
+	cells := make([]int8, 0)
+	for _, row := range s.Matrix {
+		cells = append(cells, row...)
+	}
+	if c.Distance == HammingDistance {
+		prepared.bits = make([]uint64, (len(cells)+63)/64)
+		for i, v := range cells {
+			if v != 0 {
+				prepared.bits[i/64] |= 1 << uint(i%64)
+			}
+		}
+		return prepared
+	}
+	prepared.vector = make([]float32, len(cells))
+	for i, v := range cells {
+		prepared.vector[i] = float32(v)
+	}
+	if s.Ink != nil {
+		i := 0
+		for _, row := range s.Ink {
+			for _, v := range row {
+				if i < len(prepared.vector) {
+					prepared.vector[i] = float32(v) / 255
+				}
+				i++
+			}
+		}
+	}
+	return prepared
+}
+
+// distance returns the distance between two prepared samples
+// Squared euclidean distances are returned since only their order matters
+func (c *KNN) distance(a, b *knnSample) float64 {
+	if c.Distance == HammingDistance {
+		n := 0
+		for i := range a.bits {
+			if i < len(b.bits) {
+				n += bits.OnesCount64(a.bits[i] ^ b.bits[i])
+			}
+		}
+		return float64(n)
+	}
+	var sum float32
+	for i := range a.vector {
+		if i < len(b.vector) {
+			d := a.vector[i] - b.vector[i]
+			sum += d * d
+		}
+	}
+	return float64(sum)
+}
+
+// Predict returns up to n labels voted for by the K nearest training samples of s,
+// the most likely first. Ties in the votes go to the label of the nearer neighbour
+func (c *KNN) Predict(s Sample, n int) []Prediction {
+	query := c.prepare(s)
+	type neighbour struct {
+		label    int
+		distance float64
+	}
+	nearest := make([]neighbour, 0, c.K+1)
+	for i := range c.samples {
+		d := c.distance(&query, &c.samples[i])
+		if len(nearest) == c.K && d >= nearest[len(nearest)-1].distance {
+			continue
+		}
+		// Insert sorted by distance, keeping the first K
+		j := len(nearest)
+		if j < c.K {
+			nearest = append(nearest, neighbour{})
+		} else {
+			j--
+		}
+		for ; j > 0 && nearest[j-1].distance > d; j-- {
+			nearest[j] = nearest[j-1]
+		}
+		nearest[j] = neighbour{label: c.samples[i].label, distance: d}
+	}
+	if len(nearest) == 0 {
+		return nil
+	}
+
+	votes := make(map[int]int)
+	rank := make(map[int]int) // Position of the nearest neighbour of each label
+	for i, neighbour := range nearest {
+		if votes[neighbour.label] == 0 {
+			rank[neighbour.label] = i
+		}
+		votes[neighbour.label]++
+	}
+	labels := make([]int, 0, len(votes))
+	for label := range votes {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if votes[labels[i]] != votes[labels[j]] {
+			return votes[labels[i]] > votes[labels[j]]
+		}
+		return rank[labels[i]] < rank[labels[j]]
+	})
+	if n > 0 && len(labels) > n {
+		labels = labels[:n]
+	}
+	predictions := make([]Prediction, len(labels))
+	for i, label := range labels {
+		predictions[i] = Prediction{Label: c.labels[label], Confidence: float64(votes[label]) / float64(len(nearest))}
+	}
+	return predictions
+}
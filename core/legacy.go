@@ -0,0 +1,107 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/gob"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LegacyProject is the gob encoded layout of project files written before the zip format
+// It is only read to migrate old projects and must keep the layout of those files.
+// MatrixRow and MatrixCol hold the matrix size plus one. Samples saved in MATLAB
+// mode are stored flattened in TempData, samples saved in CSV mode as CSV rows in Buffer.
+type LegacyProject struct {
+	Options struct {
+		FlatMatrix           bool
+		MatlabSaveFormat     bool
+		DotMFileWithVariable bool
+		MatrixCol            int
+		MatrixRow            int
+		SettingsSaved        bool
+		OneHotEncodingSave   bool
+	}
+	TempData struct {
+		Saved      bool
+		TempMatrix [][]int8
+		TempTarget []string
+	}
+	OneHotDictionary struct {
+		Dictionary map[string]interface{}
+		Values     []string
+	}
+	CounterValue   string
+	DataFilePath   string
+	TargetFilePath string
+	Buffer         []byte
+}
+
+// ReadLegacyProject decodes a gob project file of older versions
+func ReadLegacyProject(data []byte) (*LegacyProject, error) {
+	p := &LegacyProject{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Dataset rebuilds the samples of the project
+func (p *LegacyProject) Dataset() (*Dataset, error) {
+	rows, cols := p.Options.MatrixRow-1, p.Options.MatrixCol-1
+	if rows <= 0 || cols <= 0 {
+		return nil, fmt.Errorf("invalid matrix size %dx%d", rows, cols)
+	}
+	d := NewDataset(rows, cols)
+	for i, values := range p.TempData.TempMatrix {
+		if i >= len(p.TempData.TempTarget) {
+			return nil, fmt.Errorf("missing label for sample %d", i)
+		}
+		matrix, err := Unflatten(values, cols)
+		if err != nil {
+			return nil, err
+		}
+		if err = d.Add(matrix, p.TempData.TempTarget[i]); err != nil {
+			return nil, err
+		}
+	}
+	if len(p.Buffer) > 0 {
+		if err := parseLegacyBuffer(p.Buffer, d); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// parseLegacyBuffer reads the CSV rows kept by old project files
+// Each row holds a matrix printed either as [[a b] [c d]] or flattened as [a b c d]
+func parseLegacyBuffer(buffer []byte, d *Dataset) error {
+	reader := csv.NewReader(bytes.NewReader(buffer))
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if len(record) != 2 {
+			return fmt.Errorf("invalid row %q", record)
+		}
+		fields := strings.Fields(strings.NewReplacer("[", " ", "]", " ").Replace(record[0]))
+		values := make([]int8, len(fields))
+		for i, field := range fields {
+			v, err := strconv.Atoi(field)
+			if err != nil {
+				return err
+			}
+			values[i] = int8(v)
+		}
+		matrix, err := Unflatten(values, d.Cols)
+		if err != nil {
+			return err
+		}
+		if err = d.Add(matrix, record[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+)
+
+// baselineProject mirrors the project file of the first versions, which
+// encoded a package variable with this layout
+type baselineProject struct {
+	Options struct {
+		FlatMatrix           bool
+		MatlabSaveFormat     bool
+		DotMFileWithVariable bool
+		MatrixCol            int
+		MatrixRow            int
+		SettingsSaved        bool
+		OneHotEncodingSave   bool
+	}
+	TempData struct {
+		Saved      bool
+		buffer     bytes.Buffer
+		TempMatrix [][]int8
+		TempTarget []string
+	}
+	OneHotDictionary struct {
+		Dictionary map[string]interface{}
+		Values     []string
+	}
+	CounterValue   string
+	DataFilePath   string
+	TargetFilePath string
+	Buffer         []byte
+}
+
+// encodeBaselineProject returns p as written by the first versions
+func encodeBaselineProject(t *testing.T, p baselineProject) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(p); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadLegacyProject(t *testing.T) {
+	matlab := baselineProject{}
+	matlab.Options.MatlabSaveFormat = true
+	matlab.Options.OneHotEncodingSave = true
+	matlab.Options.SettingsSaved = true
+	matlab.Options.MatrixRow, matlab.Options.MatrixCol = 3, 3
+	matlab.TempData.TempMatrix = [][]int8{{1, 0, 0, 1}, {1, 1, 0, 0}}
+	matlab.TempData.TempTarget = []string{"a", "b"}
+	matlab.OneHotDictionary.Dictionary = map[string]interface{}{"a": 0, "b": 1}
+	matlab.OneHotDictionary.Values = []string{"a", "b"}
+	matlab.CounterValue = "2"
+
+	csv := baselineProject{}
+	csv.Options.FlatMatrix = true
+	csv.Options.MatrixRow, csv.Options.MatrixCol = 3, 3
+	csv.Buffer = []byte("[[1 0] [0 1]],a\r\n[1 1 0 0],b\r\n")
+
+	tests := []struct {
+		name    string
+		project baselineProject
+		want    []Sample
+	}{
+		{"matlab", matlab, []Sample{
+			{ID: 1, Matrix: testMatrix("10", "01"), Label: "a"},
+			{ID: 2, Matrix: testMatrix("11", "00"), Label: "b"},
+		}},
+		{"csv", csv, []Sample{
+			{ID: 1, Matrix: testMatrix("10", "01"), Label: "a"},
+			{ID: 2, Matrix: testMatrix("11", "00"), Label: "b"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ReadLegacyProject(encodeBaselineProject(t, tt.project))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Options != tt.project.Options {
+				t.Errorf("Options = %+v, want %+v", p.Options, tt.project.Options)
+			}
+			d, err := p.Dataset()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Rows != 2 || d.Cols != 2 {
+				t.Errorf("matrix size = %dx%d, want 2x2", d.Rows, d.Cols)
+			}
+			if got := d.Samples(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("samples = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadLegacyProjectErrors(t *testing.T) {
+	if _, err := ReadLegacyProject([]byte("PK\x03\x04")); err == nil {
+		t.Error("ReadLegacyProject() of a zip file succeeded")
+	}
+
+	tests := []struct {
+		name   string
+		change func(p *baselineProject)
+	}{
+		{"missing label", func(p *baselineProject) {
+			p.TempData.TempMatrix = [][]int8{{1, 0, 0, 1}}
+		}},
+		{"wrong size", func(p *baselineProject) {
+			p.TempData.TempMatrix = [][]int8{{1, 0, 0}}
+			p.TempData.TempTarget = []string{"a"}
+		}},
+		{"bad csv row", func(p *baselineProject) {
+			p.Buffer = []byte("[[1 x] [0 1]],a\r\n")
+		}},
+		{"no matrix size", func(p *baselineProject) {
+			p.Options.MatrixRow = 0
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := baselineProject{}
+			project.Options.MatrixRow, project.Options.MatrixCol = 3, 3
+			tt.change(&project)
+			p, err := ReadLegacyProject(encodeBaselineProject(t, project))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = p.Dataset(); err == nil {
+				t.Error("Dataset() succeeded")
+			}
+		})
+	}
+}
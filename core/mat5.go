@@ -0,0 +1,190 @@
+package core
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+	"unicode/utf16"
+)
+
+// MAT-file Level 5 data types and array classes
+const (
+	miInt8       = 1
+	miUint16     = 4
+	miInt32      = 5
+	miUint32     = 6
+	miDouble     = 9
+	miMatrix     = 14
+	miCompressed = 15
+
+	mxCellClass   = 1
+	mxCharClass   = 4
+	mxDoubleClass = 6
+)
+
+// matVariableName matches names MATLAB accepts as variables
+var matVariableName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,62}$`)
+
+// MatExporter writes the dataset as a binary MAT-file (Level 5)
+// that MATLAB and Octave read directly with load(). The file holds three
+// variables: the data matrix with one flattened sample per column, the
+// targets and a cell array with the class names.
+type MatExporter struct {
+	DataFileName   string // File and data variable name
+	TargetFileName string // Target variable name
+	OneHot         bool   // Whether targets are a one-hot matrix instead of class indices
+	Compress       bool   // Whether to zlib-compress the variables
+	Values         Values // Cell values to write
+}
+
+// Files implements Exporter
+func (e MatExporter) Files(dirPath string) []string {
+	return []string{filepath.Join(dirPath, e.DataFileName+".mat")}
+}
+
+// Export implements Exporter
+// Targets are 1-based class indices into the classes variable, or with
+// OneHot a classes x samples matrix
+func (e MatExporter) Export(dirPath string, d *Dataset) error {
+	for _, name := range []string{e.DataFileName, e.TargetFileName} {
+		if !matVariableName.MatchString(name) {
+			return fmt.Errorf("%q is not a valid MATLAB variable name", name)
+		}
+	}
+	if e.DataFileName == e.TargetFileName || e.DataFileName == "classes" || e.TargetFileName == "classes" {
+		return fmt.Errorf("data, target and classes variables need different names")
+	}
+
+	samples := d.Samples()
+	classes := d.Labels()
+	index := make(map[string]int, len(classes))
+	for i, label := range classes {
+		index[label] = i
+	}
+
+	features := d.Rows * d.Cols
+	data := make([]float64, 0, features*len(samples))
+	for _, s := range samples {
+		data = append(data, Flatten(e.Values.Matrix(s))...)
+	}
+
+	var targets []byte
+	if e.OneHot {
+		values := make([]float64, len(classes)*len(samples))
+		for j, s := range samples {
+			values[j*len(classes)+index[s.Label]] = 1
+		}
+		targets = matDouble(e.TargetFileName, len(classes), len(samples), values)
+	} else {
+		values := make([]float64, len(samples))
+		for j, s := range samples {
+			values[j] = float64(index[s.Label] + 1)
+		}
+		targets = matDouble(e.TargetFileName, 1, len(samples), values)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(matHeader())
+	for _, variable := range [][]byte{
+		matDouble(e.DataFileName, features, len(samples), data),
+		targets,
+		matCellOfStrings("classes", classes),
+	} {
+		if !e.Compress {
+			buf.Write(variable)
+			continue
+		}
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(variable); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		buf.Write(matTag(miCompressed, compressed.Len()))
+		buf.Write(compressed.Bytes())
+	}
+	return os.WriteFile(e.Files(dirPath)[0], buf.Bytes(), 0600)
+}
+
+// matHeader returns the 128 byte MAT-file header for a little-endian file
+func matHeader() []byte {
+	header := bytes.Repeat([]byte{' '}, 128)
+	text := "MATLAB 5.0 MAT-file, Platform: Draw2Matrix, Created on: " + time.Now().Format(time.ANSIC)
+	copy(header, text)
+	for i := 116; i < 124; i++ {
+		header[i] = 0
+	}
+	binary.LittleEndian.PutUint16(header[124:], 0x0100)
+	header[126], header[127] = 'I', 'M'
+	return header
+}
+
+// matTag returns a data element tag
+func matTag(dataType, size int) []byte {
+	tag := make([]byte, 8)
+	binary.LittleEndian.PutUint32(tag, uint32(dataType))
+	binary.LittleEndian.PutUint32(tag[4:], uint32(size))
+	return tag
+}
+
+// matElement returns a data element padded to a multiple of 8 bytes
+func matElement(dataType int, data []byte) []byte {
+	element := append(matTag(dataType, len(data)), data...)
+	if rem := len(element) % 8; rem != 0 {
+		element = append(element, make([]byte, 8-rem)...)
+	}
+	return element
+}
+
+// matMatrix returns a miMatrix element with the given class, dimensions and name
+// followed by the already encoded sub-elements of its content
+func matMatrix(class, rows, cols int, name string, content ...[]byte) []byte {
+	flags := make([]byte, 8)
+	binary.LittleEndian.PutUint32(flags, uint32(class))
+	dims := make([]byte, 8)
+	binary.LittleEndian.PutUint32(dims, uint32(rows))
+	binary.LittleEndian.PutUint32(dims[4:], uint32(cols))
+
+	body := append(matElement(miUint32, flags), matElement(miInt32, dims)...)
+	body = append(body, matElement(miInt8, []byte(name))...)
+	for _, c := range content {
+		body = append(body, c...)
+	}
+	return append(matTag(miMatrix, len(body)), body...)
+}
+
+// matDouble returns a rows x cols double matrix from column-major values
+func matDouble(name string, rows, cols int, values []float64) []byte {
+	data := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(data[8*i:], math.Float64bits(v))
+	}
+	return matMatrix(mxDoubleClass, rows, cols, name, matElement(miDouble, data))
+}
+
+// matString returns a 1 x n char array
+func matString(name, value string) []byte {
+	units := utf16.Encode([]rune(value))
+	data := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(data[2*i:], u)
+	}
+	return matMatrix(mxCharClass, 1, len(units), name, matElement(miUint16, data))
+}
+
+// matCellOfStrings returns a 1 x n cell array of char arrays
+func matCellOfStrings(name string, values []string) []byte {
+	cells := make([][]byte, len(values))
+	for i, v := range values {
+		cells[i] = matString("", v)
+	}
+	return matMatrix(mxCellClass, 1, len(values), name, cells...)
+}
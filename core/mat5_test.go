@@ -0,0 +1,207 @@
+package core
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// readMatElement splits the next data element off b and checks its padding
+func readMatElement(t *testing.T, b []byte) (dataType int, data, rest []byte) {
+	t.Helper()
+	if len(b) < 8 {
+		t.Fatalf("%d bytes left, want a tag", len(b))
+	}
+	dataType = int(binary.LittleEndian.Uint32(b))
+	size := int(binary.LittleEndian.Uint32(b[4:]))
+	end := 8 + size
+	if dataType != miMatrix && dataType != miCompressed {
+		end = (end + 7) / 8 * 8
+	}
+	if end > len(b) {
+		t.Fatalf("element of %d bytes exceeds the %d bytes left", size, len(b)-8)
+	}
+	return dataType, b[8 : 8+size], b[end:]
+}
+
+// matVariable is a decoded miMatrix element
+type matVariable struct {
+	class      int
+	rows, cols int
+	name       string
+	content    []byte
+}
+
+// readMatVariable decodes the next variable of a MAT-file body
+func readMatVariable(t *testing.T, b []byte) (matVariable, []byte) {
+	t.Helper()
+	dataType, body, rest := readMatElement(t, b)
+	if dataType == miCompressed {
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body, err = io.ReadAll(zr); err != nil {
+			t.Fatal(err)
+		}
+		v, _ := readMatVariable(t, body)
+		return v, rest
+	}
+	if dataType != miMatrix {
+		t.Fatalf("data type %d, want miMatrix", dataType)
+	}
+	if len(body)%8 != 0 {
+		t.Errorf("matrix body of %d bytes is not 8-byte aligned", len(body))
+	}
+	var v matVariable
+	_, flags, body := readMatElement(t, body)
+	_, dims, body := readMatElement(t, body)
+	_, name, body := readMatElement(t, body)
+	v.class = int(binary.LittleEndian.Uint32(flags))
+	v.rows = int(binary.LittleEndian.Uint32(dims))
+	v.cols = int(binary.LittleEndian.Uint32(dims[4:]))
+	v.name = string(name)
+	v.content = body
+	return v, rest
+}
+
+// matDoubles decodes the content of a double matrix
+func matDoubles(t *testing.T, content []byte) []float64 {
+	t.Helper()
+	dataType, data, _ := readMatElement(t, content)
+	if dataType != miDouble {
+		t.Fatalf("data type %d, want miDouble", dataType)
+	}
+	values := make([]float64, len(data)/8)
+	for i := range values {
+		values[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+	}
+	return values
+}
+
+func TestMatElementPadding(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 8, 9, 16} {
+		element := matElement(miInt8, make([]byte, n))
+		if len(element)%8 != 0 {
+			t.Errorf("element with %d bytes has length %d, want a multiple of 8", n, len(element))
+		}
+		if size := binary.LittleEndian.Uint32(element[4:]); int(size) != n {
+			t.Errorf("tag size = %d, want %d", size, n)
+		}
+	}
+}
+
+func TestMatHeader(t *testing.T) {
+	header := matHeader()
+	if len(header) != 128 {
+		t.Fatalf("header length = %d, want 128", len(header))
+	}
+	if !bytes.HasPrefix(header, []byte("MATLAB 5.0 MAT-file")) {
+		t.Errorf("header text = %q", header[:116])
+	}
+	if version := binary.LittleEndian.Uint16(header[124:]); version != 0x0100 {
+		t.Errorf("version = %#x, want 0x0100", version)
+	}
+	if string(header[126:]) != "IM" {
+		t.Errorf("endian indicator = %q, want IM", header[126:])
+	}
+}
+
+func TestMatCellOfStrings(t *testing.T) {
+	v, rest := readMatVariable(t, matCellOfStrings("classes", []string{"a", "é€"}))
+	if len(rest) != 0 || v.class != mxCellClass || v.rows != 1 || v.cols != 2 || v.name != "classes" {
+		t.Fatalf("cell = %+v with %d bytes left", v, len(rest))
+	}
+	var got []string
+	for body := v.content; len(body) > 0; {
+		var cell matVariable
+		cell, body = readMatVariable(t, body)
+		_, data, _ := readMatElement(t, cell.content)
+		var units []uint16
+		for i := 0; i < len(data); i += 2 {
+			units = append(units, binary.LittleEndian.Uint16(data[i:]))
+		}
+		if cell.class != mxCharClass || cell.cols != len(units) {
+			t.Errorf("cell class %d with %d columns, want char with %d", cell.class, cell.cols, len(units))
+		}
+		got = append(got, string(utf16.Decode(units)))
+	}
+	if want := []string{"a", "é€"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("classes = %q, want %q", got, want)
+	}
+}
+
+func TestMatExporter(t *testing.T) {
+	d := testDataset(t, "a", "b", "a")
+	tests := []struct {
+		name        string
+		exporter    MatExporter
+		targetRows  int
+		wantTargets []float64
+	}{
+		{"indices", MatExporter{DataFileName: "data", TargetFileName: "target"}, 1, []float64{1, 2, 1}},
+		{"one-hot compressed", MatExporter{DataFileName: "data", TargetFileName: "target", OneHot: true, Compress: true}, 2, []float64{1, 0, 0, 1, 1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := tt.exporter.Export(dir, d); err != nil {
+				t.Fatal(err)
+			}
+			file := []byte(readTestFile(t, filepath.Join(dir, "data.mat")))
+			body := file[128:]
+			if dataType, _, _ := readMatElement(t, body); tt.exporter.Compress != (dataType == miCompressed) {
+				t.Errorf("first element has data type %d with Compress %v", dataType, tt.exporter.Compress)
+			}
+
+			var data, target, classes matVariable
+			data, body = readMatVariable(t, body)
+			target, body = readMatVariable(t, body)
+			classes, body = readMatVariable(t, body)
+			if len(body) != 0 {
+				t.Errorf("%d bytes after the last variable", len(body))
+			}
+			if data.name != "data" || data.rows != 4 || data.cols != 3 {
+				t.Errorf("data is %s %dx%d, want data 4x3", data.name, data.rows, data.cols)
+			}
+			if got, want := matDoubles(t, data.content), []float64{1, 0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 1}; !reflect.DeepEqual(got, want) {
+				t.Errorf("data = %v, want %v", got, want)
+			}
+			if target.name != "target" || target.rows != tt.targetRows || target.cols != 3 {
+				t.Errorf("target is %s %dx%d, want target %dx3", target.name, target.rows, target.cols, tt.targetRows)
+			}
+			if got := matDoubles(t, target.content); !reflect.DeepEqual(got, tt.wantTargets) {
+				t.Errorf("targets = %v, want %v", got, tt.wantTargets)
+			}
+			if classes.name != "classes" || classes.class != mxCellClass || classes.cols != 2 {
+				t.Errorf("classes = %+v, want a 1x2 cell", classes)
+			}
+		})
+	}
+}
+
+func TestMatExporterVariableNames(t *testing.T) {
+	tests := []struct {
+		name         string
+		data, target string
+	}{
+		{"leading digit", "1data", "target"},
+		{"space", "data", "my target"},
+		{"same name", "data", "data"},
+		{"classes", "classes", "target"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := MatExporter{DataFileName: tt.data, TargetFileName: tt.target}
+			if err := e.Export(t.TempDir(), testDataset(t, "a")); err == nil {
+				t.Errorf("Export() with variables %q and %q succeeded", tt.data, tt.target)
+			}
+		})
+	}
+}
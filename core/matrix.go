@@ -0,0 +1,166 @@
+// Package core implements the GUI-independent part of Draw2Matrix: converting
+// drawings to matrices, keeping the collected samples and exporting them.
+package core
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// FlatDirection represents the direction for flattening a matrix
+type FlatDirection int8
+
+const (
+	// RowFlat indicates row-wise flattening of matrix
+	RowFlat FlatDirection = iota
+	// ColFlat indicates column-wise flattening of matrix
+	ColFlat
+)
+
+// Number is the element type of the matrices: binary cells, ink intensities or exported values
+type Number interface {
+	~int8 | ~uint8 | ~float64
+}
+
+// Transpose converts a matrix to its transpose form
+func Transpose[T Number](matrix [][]T) [][]T {
+	if len(matrix) == 0 {
+		return [][]T{}
+	}
+
+	rows := len(matrix)
+	cols := len(matrix[0])
+	transposed := make([][]T, cols)
+	for i := range transposed {
+		transposed[i] = make([]T, rows)
+	}
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			transposed[j][i] = matrix[i][j]
+		}
+	}
+
+	return transposed
+}
+
+// Flatten converts a 2D matrix into a 1D slice
+func Flatten[T Number](matrix [][]T) []T {
+	result := make([]T, 0)
+	for _, row := range matrix {
+		result = append(result, row...)
+	}
+	return result
+}
+
+// Unflatten reshapes a 1D slice back into a matrix with the given number of
+// columns. It returns an error when the length is not a multiple of cols.
+func Unflatten[T Number](values []T, cols int) ([][]T, error) {
+	if cols <= 0 || len(values)%cols != 0 {
+		return nil, fmt.Errorf("cannot reshape %d values into %d columns", len(values), cols)
+	}
+	result := make([][]T, len(values)/cols)
+	for i := range result {
+		result[i] = append([]T(nil), values[i*cols:(i+1)*cols]...)
+	}
+	return result, nil
+}
+
+// FlattenString converts a 2D matrix to a string representation
+// based on the specified flattening direction (row-wise or column-wise)
+func FlattenString[T Number](matrix [][]T, direction FlatDirection) string {
+	flattenedMatrix := Flatten(matrix)
+	if direction == RowFlat {
+		return fmt.Sprintf("%v", flattenedMatrix)
+	} else if direction == ColFlat {
+		var elements []string
+		for _, element := range flattenedMatrix {
+			elements = append(elements, fmt.Sprintf("%v", element))
+		}
+		return strings.Join(elements, "\n")
+	}
+	return ""
+}
+
+// MatlabString formats a matrix as a MATLAB matrix literal
+func MatlabString[T Number](matrix [][]T) string {
+	var result strings.Builder
+	result.Grow(len(matrix))
+	result.WriteString("[ ")
+	for i, row := range matrix {
+		for _, element := range row {
+			result.WriteString(fmt.Sprintf("%v ", element))
+		}
+		if i < len(matrix)-1 {
+			result.WriteString(";\n")
+		}
+	}
+	result.WriteString("]")
+	return result.String()
+}
+
+// OneHot encodes label against the ordered list of class names
+func OneHot(label string, classes []string) []int8 {
+	result := make([]int8, len(classes))
+	for i, value := range classes {
+		if value == label {
+			result[i] = 1
+		}
+	}
+	return result
+}
+
+// MatrixImage draws a matrix as a grayscale image with one pixel per cell
+// Cells set to 1 are black, cells set to 0 are white
+func MatrixImage(matrix [][]int8) *image.Gray {
+	rows := len(matrix)
+	cols := 0
+	if rows > 0 {
+		cols = len(matrix[0])
+	}
+	img := image.NewGray(image.Rect(0, 0, cols, rows))
+	for y, row := range matrix {
+		for x, v := range row {
+			if v == 0 {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return img
+}
+
+// HeatmapImage draws values from 0 to 1 as a heatmap with one pixel per cell
+// 0 is white and higher values go through yellow and red to black
+func HeatmapImage(values [][]float64) *image.RGBA {
+	rows := len(values)
+	cols := 0
+	if rows > 0 {
+		cols = len(values[0])
+	}
+	img := image.NewRGBA(image.Rect(0, 0, cols, rows))
+	for y, row := range values {
+		for x, v := range row {
+			img.SetRGBA(x, y, HeatColor(v))
+		}
+	}
+	return img
+}
+
+// HeatColor returns the heatmap color of a value from 0 to 1
+// White to yellow, yellow to red and red to black take one third each
+func HeatColor(v float64) color.RGBA {
+	v = math.Min(math.Max(v, 0), 1)
+	r, g, b := 1.0, 1.0, 1.0
+	switch {
+	case v < 1.0/3:
+		b = 1 - 3*v
+	case v < 2.0/3:
+		g, b = 2-3*v, 0
+	default:
+		r, g, b = 3-3*v, 0, 0
+	}
+	return color.RGBA{R: uint8(r * 255), G: uint8(g * 255), B: uint8(b * 255), A: 255}
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranspose(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix [][]int8
+		want   [][]int8
+	}{
+		{"empty", [][]int8{}, [][]int8{}},
+		{"row", [][]int8{{1, 0, 1}}, [][]int8{{1}, {0}, {1}}},
+		{"square", [][]int8{{1, 2}, {3, 4}}, [][]int8{{1, 3}, {2, 4}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Transpose(tt.matrix); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Transpose() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlattenUnflatten(t *testing.T) {
+	matrix := [][]uint8{{1, 2, 3}, {4, 5, 6}}
+	flat := Flatten(matrix)
+	if want := []uint8{1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(flat, want) {
+		t.Errorf("Flatten() = %v, want %v", flat, want)
+	}
+	back, err := Unflatten(flat, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, matrix) {
+		t.Errorf("Unflatten() = %v, want %v", back, matrix)
+	}
+	for _, cols := range []int{0, 4} {
+		if _, err = Unflatten(flat, cols); err == nil {
+			t.Errorf("Unflatten(%d columns) succeeded", cols)
+		}
+	}
+}
+
+func TestFlattenString(t *testing.T) {
+	matrix := [][]int8{{1, 0}, {0, 1}}
+	tests := []struct {
+		direction FlatDirection
+		want      string
+	}{
+		{RowFlat, "[1 0 0 1]"},
+		{ColFlat, "1\n0\n0\n1"},
+	}
+	for _, tt := range tests {
+		if got := FlattenString(matrix, tt.direction); got != tt.want {
+			t.Errorf("FlattenString(%d) = %q, want %q", tt.direction, got, tt.want)
+		}
+	}
+}
+
+func TestMatlabString(t *testing.T) {
+	if got, want := MatlabString([][]float64{{1, 0.5}, {0, 1}}), "[ 1 0.5 ;\n0 1 ]"; got != want {
+		t.Errorf("MatlabString() = %q, want %q", got, want)
+	}
+}
+
+func TestOneHot(t *testing.T) {
+	classes := []string{"a", "b", "c"}
+	if got, want := OneHot("b", classes), []int8{0, 1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OneHot(b) = %v, want %v", got, want)
+	}
+	if got, want := OneHot("x", classes), []int8{0, 0, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OneHot(x) = %v, want %v", got, want)
+	}
+}
+
+func TestMatrixImage(t *testing.T) {
+	img := MatrixImage(testMatrix("10", "01"))
+	for _, c := range []struct{ x, y, want int }{{0, 0, 0}, {1, 0, 255}, {0, 1, 255}, {1, 1, 0}} {
+		if got := int(img.GrayAt(c.x, c.y).Y); got != c.want {
+			t.Errorf("pixel (%d, %d) = %d, want %d", c.x, c.y, got, c.want)
+		}
+	}
+}
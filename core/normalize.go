@@ -0,0 +1,117 @@
+package core
+
+import (
+	"golang.org/x/image/draw"
+	"image"
+	"math"
+)
+
+// Centering selects how the normalised ink is positioned in the matrix
+type Centering int8
+
+const (
+	// CenterBox centres the bounding box of the ink
+	CenterBox Centering = iota
+	// CenterMass centres the centre of mass of the ink, as done for MNIST
+	CenterMass
+)
+
+// Normalization crops an image to its ink and fits it into the matrix
+// With a 28x28 matrix, a margin of 4 and CenterMass it follows the MNIST
+// preprocessing: the ink is scaled into a 20x20 box, then shifted so its
+// centre of mass lies in the middle of the matrix
+type Normalization struct {
+	Margin int       // Empty cells kept on every side of the ink
+	Center Centering // How the scaled ink is positioned
+}
+
+// inkBounds returns the bounding box of the non-white pixels of img
+// ok is false for an empty image
+func inkBounds(img *image.Gray) (bounds image.Rectangle, ok bool) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.GrayAt(x, y).Y == 255 {
+				continue
+			}
+			pixel := image.Rect(x, y, x+1, y+1)
+			if !ok {
+				bounds, ok = pixel, true
+			} else {
+				bounds = bounds.Union(pixel)
+			}
+		}
+	}
+	return bounds, ok
+}
+
+// massCenter returns the ink weighted centre of img relative to its origin
+func massCenter(img *image.Gray) (cx, cy float64) {
+	b := img.Bounds()
+	var total float64
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			w := float64(255 - img.GrayAt(x, y).Y)
+			cx += w * (float64(x-b.Min.X) + 0.5)
+			cy += w * (float64(y-b.Min.Y) + 0.5)
+			total += w
+		}
+	}
+	if total == 0 {
+		return float64(b.Dx()) / 2, float64(b.Dy()) / 2
+	}
+	return cx / total, cy / total
+}
+
+// clamp limits v to the range [low, high]
+func clamp(v, low, high int) int {
+	if v > high {
+		v = high
+	}
+	if v < low {
+		v = low
+	}
+	return v
+}
+
+// Place draws the ink of img into dst, scaled to fill dst without the margin
+// The aspect ratio of the ink is preserved. An image without ink leaves dst untouched
+func (n *Normalization) Place(dst *image.Gray, img image.Image) {
+	// Flatten the image onto white paper so transparent areas count as white
+	paper := image.NewGray(img.Bounds())
+	draw.Draw(paper, paper.Rect, image.White, image.Point{}, draw.Src)
+	draw.Draw(paper, paper.Rect, img, img.Bounds().Min, draw.Over)
+	ink, ok := inkBounds(paper)
+	if !ok {
+		return
+	}
+
+	width, height := dst.Rect.Dx(), dst.Rect.Dy()
+	innerW, innerH := width-2*n.Margin, height-2*n.Margin
+	if innerW < 1 {
+		innerW = 1
+	}
+	if innerH < 1 {
+		innerH = 1
+	}
+	scale := math.Min(float64(innerW)/float64(ink.Dx()), float64(innerH)/float64(ink.Dy()))
+	boxW := clamp(int(math.Round(float64(ink.Dx())*scale)), 1, innerW)
+	boxH := clamp(int(math.Round(float64(ink.Dy())*scale)), 1, innerH)
+
+	box := image.NewGray(image.Rect(0, 0, boxW, boxH))
+	draw.Draw(box, box.Rect, image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(box, box.Rect, paper, ink, draw.Over, nil)
+
+	// Centre the box, then keep all of it inside dst
+	x, y := (width-boxW)/2, (height-boxH)/2
+	if n.Center == CenterMass {
+		cx, cy := massCenter(box)
+		x = int(math.Round(float64(width)/2 - cx))
+		y = int(math.Round(float64(height)/2 - cy))
+	}
+	x = clamp(x, 0, width-boxW)
+	y = clamp(y, 0, height-boxH)
+
+	at := dst.Rect.Min.Add(image.Pt(x, y))
+	draw.Draw(dst, image.Rectangle{Min: at, Max: at.Add(box.Rect.Size())}, box, image.Point{}, draw.Src)
+}
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// NpyExporter writes the dataset as NumPy arrays
+// The data array is uint8, or float64 for values from 0.0 to 1.0, with shape
+// (N, Rows, Cols), or (N, Rows*Cols) when Flat is set. The target array holds int64 class indices into the classes
+// array, which lists the label names in order of first appearance.
+type NpyExporter struct {
+	DataFileName   string // Data array file name without extension
+	TargetFileName string // Target array file name without extension
+	Flat           bool   // Whether to flatten each matrix into a single row
+	Bundle         bool   // Whether to write a single .npz archive named after the data file
+	Values         Values // Cell values to write
+}
+
+// Files implements Exporter
+func (e NpyExporter) Files(dirPath string) []string {
+	if e.Bundle {
+		return []string{filepath.Join(dirPath, e.DataFileName+".npz")}
+	}
+	return []string{
+		filepath.Join(dirPath, e.DataFileName+".npy"),
+		filepath.Join(dirPath, e.TargetFileName+".npy"),
+		filepath.Join(dirPath, e.TargetFileName+"_classes.npy"),
+	}
+}
+
+// Export implements Exporter
+func (e NpyExporter) Export(dirPath string, d *Dataset) error {
+	arrays := e.arrays(d)
+	files := e.Files(dirPath)
+	if !e.Bundle {
+		for i, array := range arrays {
+			if err := os.WriteFile(files[i], array.bytes(), 0600); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	file, err := os.OpenFile(files[0], os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+	for _, array := range arrays {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: array.name + ".npy", Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(array.bytes()); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// arrays builds the data, target and classes arrays of the dataset
+func (e NpyExporter) arrays(d *Dataset) []npyArray {
+	samples := d.Samples()
+	classes := d.Labels()
+	index := make(map[string]int, len(classes))
+	for i, label := range classes {
+		index[label] = i
+	}
+
+	descr := "|u1"
+	if !e.Values.Integer() {
+		descr = "<f8"
+	}
+	data := make([]byte, 0, len(samples)*d.Rows*d.Cols)
+	targets := make([]byte, 8*len(samples))
+	for i, s := range samples {
+		for _, v := range Flatten(e.Values.Matrix(s)) {
+			if e.Values.Integer() {
+				data = append(data, byte(v))
+			} else {
+				data = binary.LittleEndian.AppendUint64(data, math.Float64bits(v))
+			}
+		}
+		binary.LittleEndian.PutUint64(targets[8*i:], uint64(index[s.Label]))
+	}
+	dataShape := []int{len(samples), d.Rows, d.Cols}
+	if e.Flat {
+		dataShape = []int{len(samples), d.Rows * d.Cols}
+	}
+
+	return []npyArray{
+		{name: "X", descr: descr, shape: dataShape, data: data},
+		{name: "y", descr: "<i8", shape: []int{len(samples)}, data: targets},
+		unicodeArray("classes", classes),
+	}
+}
+
+// npyArray is an in-memory array in the NumPy .npy layout
+type npyArray struct {
+	name  string // Array name inside an .npz archive
+	descr string // NumPy dtype descriptor
+	shape []int
+	data  []byte // Raw little-endian, C-ordered values
+}
+
+// unicodeArray creates a fixed width unicode string array ('<U') from values
+func unicodeArray(name string, values []string) npyArray {
+	width := 1
+	for _, v := range values {
+		if n := utf8.RuneCountInString(v); n > width {
+			width = n
+		}
+	}
+	data := make([]byte, 4*width*len(values))
+	for i, v := range values {
+		offset := 4 * width * i
+		for _, r := range v {
+			binary.LittleEndian.PutUint32(data[offset:], uint32(r))
+			offset += 4
+		}
+	}
+	return npyArray{name: name, descr: fmt.Sprintf("<U%d", width), shape: []int{len(values)}, data: data}
+}
+
+// bytes encodes the array in the .npy version 1.0 format
+func (a npyArray) bytes() []byte {
+	var buf bytes.Buffer
+	_ = writeNpy(&buf, a)
+	return buf.Bytes()
+}
+
+// writeNpy writes the .npy magic, the padded header dictionary and the data
+func writeNpy(w io.Writer, a npyArray) error {
+	dims := make([]string, len(a.shape))
+	for i, n := range a.shape {
+		dims[i] = fmt.Sprint(n)
+	}
+	shape := strings.Join(dims, ", ")
+	if len(a.shape) == 1 {
+		shape += ","
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", a.descr, shape)
+
+	// Magic (6) + version (2) + header length (2) + header must align to 64 bytes
+	padding := 64 - (10+len(header)+1)%64
+	if padding == 64 {
+		padding = 0
+	}
+	header += strings.Repeat(" ", padding) + "\n"
+
+	prefix := []byte{0x93, 'N', 'U', 'M', 'P', 'Y', 1, 0, 0, 0}
+	binary.LittleEndian.PutUint16(prefix[8:], uint16(len(header)))
+	for _, chunk := range [][]byte{prefix, []byte(header), a.data} {
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
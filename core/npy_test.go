@@ -0,0 +1,171 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseNpy splits an .npy file into its header dictionary and data
+// and checks the layout every .npy reader relies on
+func parseNpy(t *testing.T, file []byte) (string, []byte) {
+	t.Helper()
+	if len(file) < 10 || !bytes.Equal(file[:8], []byte{0x93, 'N', 'U', 'M', 'P', 'Y', 1, 0}) {
+		t.Fatalf("bad magic or version: % x", file[:min(len(file), 8)])
+	}
+	n := int(binary.LittleEndian.Uint16(file[8:10]))
+	if 10+n > len(file) {
+		t.Fatalf("header length %d exceeds the file", n)
+	}
+	if (10+n)%64 != 0 {
+		t.Errorf("data starts at %d, want a multiple of 64", 10+n)
+	}
+	header := string(file[10 : 10+n])
+	if !strings.HasSuffix(header, "\n") {
+		t.Errorf("header %q does not end with a newline", header)
+	}
+	return strings.TrimRight(header, " \n"), file[10+n:]
+}
+
+func TestWriteNpyHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		array npyArray
+		want  string
+	}{
+		{"1-d", npyArray{descr: "<i8", shape: []int{3}}, "{'descr': '<i8', 'fortran_order': False, 'shape': (3,), }"},
+		{"3-d", npyArray{descr: "|u1", shape: []int{2, 28, 28}}, "{'descr': '|u1', 'fortran_order': False, 'shape': (2, 28, 28), }"},
+		{"empty", npyArray{descr: "<f8", shape: []int{0, 4}}, "{'descr': '<f8', 'fortran_order': False, 'shape': (0, 4), }"},
+		// 10 + 53 + 1 bytes, exactly one block without padding
+		{"exact fit", npyArray{descr: "<U1", shape: []int{1}}, "{'descr': '<U1', 'fortran_order': False, 'shape': (1,), }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.array.data = []byte{1, 2, 3}
+			header, data := parseNpy(t, tt.array.bytes())
+			if header != tt.want {
+				t.Errorf("header = %q, want %q", header, tt.want)
+			}
+			if !bytes.Equal(data, tt.array.data) {
+				t.Errorf("data = %v, want %v", data, tt.array.data)
+			}
+		})
+	}
+}
+
+func TestUnicodeArray(t *testing.T) {
+	a := unicodeArray("classes", []string{"a", "bcd", "é"})
+	if a.descr != "<U3" || !reflect.DeepEqual(a.shape, []int{3}) {
+		t.Errorf("descr %q shape %v, want <U3 [3]", a.descr, a.shape)
+	}
+	want := make([]byte, 0, 36)
+	for _, r := range []rune{'a', 0, 0, 'b', 'c', 'd', 'é', 0, 0} {
+		want = binary.LittleEndian.AppendUint32(want, uint32(r))
+	}
+	if !bytes.Equal(a.data, want) {
+		t.Errorf("data = % x, want % x", a.data, want)
+	}
+
+	// An empty list still has a valid width
+	if empty := unicodeArray("classes", nil); empty.descr != "<U1" || len(empty.data) != 0 {
+		t.Errorf("empty array descr %q with %d bytes, want <U1 without data", empty.descr, len(empty.data))
+	}
+}
+
+func TestNpyExporter(t *testing.T) {
+	d := testDataset(t, "a", "b", "a")
+	wantHeaders := []string{
+		"{'descr': '|u1', 'fortran_order': False, 'shape': (3, 4), }",
+		"{'descr': '<i8', 'fortran_order': False, 'shape': (3,), }",
+		"{'descr': '<U1', 'fortran_order': False, 'shape': (2,), }",
+	}
+	var targets []byte
+	for _, class := range []uint64{0, 1, 0} {
+		targets = binary.LittleEndian.AppendUint64(targets, class)
+	}
+	wantData := [][]byte{
+		bytes.Repeat([]byte{1, 0, 0, 1}, 3),
+		targets,
+		{'a', 0, 0, 0, 'b', 0, 0, 0},
+	}
+
+	check := func(t *testing.T, files [][]byte) {
+		for i, file := range files {
+			header, data := parseNpy(t, file)
+			if header != wantHeaders[i] {
+				t.Errorf("array %d header = %q, want %q", i, header, wantHeaders[i])
+			}
+			if !bytes.Equal(data, wantData[i]) {
+				t.Errorf("array %d data = %v, want %v", i, data, wantData[i])
+			}
+		}
+	}
+
+	t.Run("npy", func(t *testing.T) {
+		dir := t.TempDir()
+		e := NpyExporter{DataFileName: "X", TargetFileName: "y", Flat: true}
+		if err := e.Export(dir, d); err != nil {
+			t.Fatal(err)
+		}
+		var files [][]byte
+		for _, path := range e.Files(dir) {
+			files = append(files, []byte(readTestFile(t, path)))
+		}
+		check(t, files)
+	})
+
+	t.Run("npz", func(t *testing.T) {
+		dir := t.TempDir()
+		e := NpyExporter{DataFileName: "dataset", Flat: true, Bundle: true}
+		if err := e.Export(dir, d); err != nil {
+			t.Fatal(err)
+		}
+		archive, err := zip.OpenReader(filepath.Join(dir, "dataset.npz"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer archive.Close()
+		var names []string
+		var files [][]byte
+		for _, f := range archive.File {
+			names = append(names, f.Name)
+			r, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			files = append(files, data)
+		}
+		if want := []string{"X.npy", "y.npy", "classes.npy"}; !reflect.DeepEqual(names, want) {
+			t.Fatalf("archive holds %v, want %v", names, want)
+		}
+		check(t, files)
+	})
+}
+
+func TestNewExporterFormatCase(t *testing.T) {
+	dir := t.TempDir()
+	e, err := NewExporter("NPZ", ExportSettings{DataFileName: "data", TargetFileName: "target"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = e.Export(dir, testDataset(t, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "data.npz")); err != nil {
+		t.Errorf("format NPZ did not write data.npz: %v", err)
+	}
+	if _, err = NewExporter("parquet", ExportSettings{}); err == nil {
+		t.Error("NewExporter() of an unknown format succeeded")
+	}
+}
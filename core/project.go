@@ -0,0 +1,331 @@
+package core
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Project files are zip archives holding JSON documents:
+//
+//	manifest.json   format name, schema version, matrix size, labels and application settings
+//	samples.jsonl   one sample per line: id, parent, label, time, matrix and ink
+//	strokes.jsonl   one drawing per line for the samples that have strokes, keyed by sample id
+//
+// Matrices are written as one string of 0 and 1 per row, ink intensities as
+// one array of numbers from 0 to 255 per row. Readers ignore unknown fields, so
+// newer versions may add fields without changing the schema version.
+const (
+	ProjectFormat     = "draw2matrix-project"
+	ProjectVersion    = 1
+	manifestFile      = "manifest.json"
+	samplesFile       = "samples.jsonl"
+	strokesFile       = "strokes.jsonl"
+	projectTimeLayout = time.RFC3339Nano
+)
+
+// Project is a dataset with the settings it was collected with
+type Project struct {
+	Version  int             // Schema version the project was read from, ProjectVersion when written
+	Saved    time.Time       // When the project was written
+	Settings json.RawMessage // Application settings, stored as given
+	Dataset  *Dataset
+}
+
+// projectManifest is the layout of manifest.json
+type projectManifest struct {
+	Format   string          `json:"format"`
+	Version  int             `json:"version"`
+	Saved    string          `json:"saved,omitempty"`
+	Rows     int             `json:"rows"`
+	Cols     int             `json:"cols"`
+	Samples  int             `json:"samples"`
+	Labels   []string        `json:"labels"`
+	Settings json.RawMessage `json:"settings,omitempty"`
+}
+
+// projectSample is the layout of a line of samples.jsonl
+type projectSample struct {
+	ID     uint64   `json:"id"`
+	Parent uint64   `json:"parent,omitempty"`
+	Label  string   `json:"label"`
+	Time   string   `json:"time,omitempty"`
+	Matrix []string `json:"matrix"`
+	Ink    [][]int  `json:"ink,omitempty"`
+}
+
+// projectDrawing is the layout of a line of strokes.jsonl
+// Every point is written as [x, y, milliseconds]
+type projectDrawing struct {
+	ID      uint64          `json:"id"`
+	Width   float32         `json:"width"`
+	Height  float32         `json:"height"`
+	Strokes []projectStroke `json:"strokes"`
+}
+
+// projectStroke is the layout of a stroke in strokes.jsonl
+type projectStroke struct {
+	Width  float32      `json:"width"`
+	Points [][3]float64 `json:"points"`
+}
+
+// newProjectSample returns the record of s, without its drawing
+func newProjectSample(s Sample) projectSample {
+	record := projectSample{ID: s.ID, Parent: s.Parent, Label: s.Label, Matrix: make([]string, len(s.Matrix))}
+	if !s.Time.IsZero() {
+		record.Time = s.Time.Format(projectTimeLayout)
+	}
+	for y, row := range s.Matrix {
+		var b strings.Builder
+		for _, v := range row {
+			b.WriteByte('0' + byte(v))
+		}
+		record.Matrix[y] = b.String()
+	}
+	if s.Ink != nil {
+		record.Ink = make([][]int, len(s.Ink))
+		for y, row := range s.Ink {
+			record.Ink[y] = make([]int, len(row))
+			for x, v := range row {
+				record.Ink[y][x] = int(v)
+			}
+		}
+	}
+	return record
+}
+
+// sample returns the sample of the record with the given drawing
+func (record projectSample) sample(drawing *Drawing) (Sample, error) {
+	s := Sample{ID: record.ID, Parent: record.Parent, Label: record.Label, Drawing: drawing}
+	if record.Time != "" {
+		var err error
+		if s.Time, err = time.Parse(projectTimeLayout, record.Time); err != nil {
+			return s, err
+		}
+	}
+	s.Matrix = make([][]int8, len(record.Matrix))
+	for y, row := range record.Matrix {
+		s.Matrix[y] = make([]int8, len(row))
+		for x, c := range []byte(row) {
+			if c != '0' && c != '1' {
+				return s, fmt.Errorf("sample %d: matrix cell %q is not 0 or 1", record.ID, c)
+			}
+			s.Matrix[y][x] = int8(c - '0')
+		}
+	}
+	if record.Ink != nil {
+		s.Ink = make([][]uint8, len(record.Ink))
+		for y, row := range record.Ink {
+			s.Ink[y] = make([]uint8, len(row))
+			for x, v := range row {
+				if v < 0 || v > 255 {
+					return s, fmt.Errorf("sample %d: ink %d is out of range", record.ID, v)
+				}
+				s.Ink[y][x] = uint8(v)
+			}
+		}
+	}
+	return s, nil
+}
+
+// newProjectDrawing returns the record of the drawing of sample id
+func newProjectDrawing(id uint64, d Drawing) projectDrawing {
+	record := projectDrawing{ID: id, Width: d.Width, Height: d.Height, Strokes: make([]projectStroke, len(d.Strokes))}
+	for i, stroke := range d.Strokes {
+		record.Strokes[i].Width = stroke.Width
+		record.Strokes[i].Points = make([][3]float64, len(stroke.Points))
+		for j, point := range stroke.Points {
+			record.Strokes[i].Points[j] = [3]float64{float64(point.X), float64(point.Y), float64(point.T)}
+		}
+	}
+	return record
+}
+
+// drawing returns the drawing of the record
+func (record projectDrawing) drawing() *Drawing {
+	drawing := &Drawing{Width: record.Width, Height: record.Height, Strokes: make([]Stroke, len(record.Strokes))}
+	for i, stroke := range record.Strokes {
+		drawing.Strokes[i] = Stroke{Width: stroke.Width, Points: make([]Point, len(stroke.Points))}
+		for j, point := range stroke.Points {
+			drawing.Strokes[i].Points[j] = Point{X: float32(point[0]), Y: float32(point[1]), T: int64(point[2])}
+		}
+	}
+	return drawing
+}
+
+// IsProjectArchive reports whether data starts like a zip project file
+// Projects written before the zip format are gob streams
+func IsProjectArchive(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+// WriteProject writes p to w as a zip project file
+func WriteProject(w io.Writer, p Project) error {
+	d := p.Dataset
+	archive := zip.NewWriter(w)
+	manifest := projectManifest{
+		Format:   ProjectFormat,
+		Version:  ProjectVersion,
+		Rows:     d.Rows,
+		Cols:     d.Cols,
+		Samples:  d.Len(),
+		Labels:   d.Labels(),
+		Settings: p.Settings,
+	}
+	if !p.Saved.IsZero() {
+		manifest.Saved = p.Saved.Format(projectTimeLayout)
+	}
+	if err := writeJSONFile(archive, manifestFile, manifest); err != nil {
+		return err
+	}
+
+	samples, err := archive.Create(samplesFile)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(samples)
+	for _, s := range d.Samples() {
+		if err = encoder.Encode(newProjectSample(s)); err != nil {
+			return err
+		}
+	}
+
+	strokes, err := archive.Create(strokesFile)
+	if err != nil {
+		return err
+	}
+	encoder = json.NewEncoder(strokes)
+	for _, s := range d.Samples() {
+		if s.Drawing == nil {
+			continue
+		}
+		record := newProjectDrawing(s.ID, *s.Drawing)
+		if err = encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// writeJSONFile adds name to the archive holding v as indented JSON
+func writeJSONFile(archive *zip.Writer, name string, v interface{}) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// ReadProject reads a zip project file
+// Projects with a newer schema version than ProjectVersion are rejected
+func ReadProject(data []byte) (*Project, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var manifest projectManifest
+	if err = readJSONFile(files, manifestFile, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Format != ProjectFormat {
+		return nil, fmt.Errorf("not a Draw2Matrix project")
+	}
+	if manifest.Version < 1 || manifest.Version > ProjectVersion {
+		return nil, fmt.Errorf("project version %d is not supported, this version reads up to %d", manifest.Version, ProjectVersion)
+	}
+	p := &Project{Version: manifest.Version, Settings: manifest.Settings, Dataset: NewDataset(manifest.Rows, manifest.Cols)}
+	if manifest.Saved != "" {
+		if p.Saved, err = time.Parse(projectTimeLayout, manifest.Saved); err != nil {
+			return nil, err
+		}
+	}
+
+	drawings := map[uint64]*Drawing{}
+	err = readJSONLines(files, strokesFile, func(decode func(interface{}) error) error {
+		var record projectDrawing
+		if err := decode(&record); err != nil {
+			return err
+		}
+		drawings[record.ID] = record.drawing()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readJSONLines(files, samplesFile, func(decode func(interface{}) error) error {
+		var record projectSample
+		if err := decode(&record); err != nil {
+			return err
+		}
+		s, err := record.sample(drawings[record.ID])
+		if err != nil {
+			return err
+		}
+		return p.Dataset.AddSample(s)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// readJSONFile decodes the JSON document name of the archive into v
+func readJSONFile(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("project has no %s", name)
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return json.NewDecoder(r).Decode(v)
+}
+
+// readJSONLines calls read for every line of the JSON lines file name of the archive
+// A missing file has no lines
+func readJSONLines(files map[string]*zip.File, name string, read func(decode func(interface{}) error) error) error {
+	f, ok := files[name]
+	if !ok {
+		return nil
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return scanJSONLines(r, name, read)
+}
+
+// scanJSONLines calls read for every non-empty line of r
+// name is used in error messages
+func scanJSONLines(r io.Reader, name string, read func(decode func(interface{}) error) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		err := read(func(v interface{}) error {
+			return json.Unmarshal(scanner.Bytes(), v)
+		})
+		if err != nil {
+			return fmt.Errorf("%s line %d: %w", name, line, err)
+		}
+	}
+	return scanner.Err()
+}
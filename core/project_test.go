@@ -0,0 +1,136 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testProjectDataset returns a dataset with a drawn sample, an augmented
+// variant of it and an imported sample with ink intensities
+func testProjectDataset(t *testing.T) *Dataset {
+	t.Helper()
+	d := NewDataset(2, 2)
+	samples := []Sample{
+		{
+			Matrix: testMatrix("10", "01"),
+			Label:  "a",
+			Time:   time.Date(2024, 5, 1, 12, 30, 0, 500, time.UTC),
+			Drawing: &Drawing{Width: 200, Height: 100, Strokes: []Stroke{
+				{Width: 8, Points: []Point{{X: 1.5, Y: 2, T: 0}, {X: 10, Y: 20.25, T: 16}}},
+				{Width: 4, Points: []Point{{X: 50, Y: 50, T: 40}}},
+			}},
+		},
+		{Matrix: testMatrix("11", "01"), Label: "a", Parent: 1},
+		{Matrix: testMatrix("00", "11"), Ink: [][]uint8{{0, 12}, {255, 128}}, Label: "b"},
+	}
+	for _, s := range samples {
+		if err := d.AddSample(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return d
+}
+
+func TestProjectRoundTrip(t *testing.T) {
+	d := testProjectDataset(t)
+	saved := time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)
+	settings := json.RawMessage(`{"MatrixRow":3,"MatrixCol":3}`)
+	var buf bytes.Buffer
+	if err := WriteProject(&buf, Project{Saved: saved, Settings: settings, Dataset: d}); err != nil {
+		t.Fatal(err)
+	}
+	if !IsProjectArchive(buf.Bytes()) {
+		t.Fatal("IsProjectArchive() = false for a written project")
+	}
+
+	p, err := ReadProject(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Version != ProjectVersion || !p.Saved.Equal(saved) {
+		t.Errorf("version %d saved %v, want %d and %v", p.Version, p.Saved, ProjectVersion, saved)
+	}
+	var gotSettings, wantSettings interface{}
+	if err = json.Unmarshal(p.Settings, &gotSettings); err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal(settings, &wantSettings)
+	if !reflect.DeepEqual(gotSettings, wantSettings) {
+		t.Errorf("settings = %s, want %s", p.Settings, settings)
+	}
+	if p.Dataset.Rows != 2 || p.Dataset.Cols != 2 {
+		t.Errorf("matrix size = %dx%d, want 2x2", p.Dataset.Rows, p.Dataset.Cols)
+	}
+	if got, want := p.Dataset.Samples(), d.Samples(); !reflect.DeepEqual(got, want) {
+		t.Errorf("samples = %+v, want %+v", got, want)
+	}
+}
+
+// testArchive returns a zip archive holding the given files
+func testArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadProjectRejects(t *testing.T) {
+	manifest := `{"format": "draw2matrix-project", "version": 1, "rows": 2, "cols": 2}`
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"not a zip file", []byte("not a project")},
+		{"no manifest", testArchive(t, map[string]string{samplesFile: ""})},
+		{"other format", testArchive(t, map[string]string{manifestFile: `{"format": "other", "version": 1}`})},
+		{"newer version", testArchive(t, map[string]string{manifestFile: `{"format": "draw2matrix-project", "version": 2}`})},
+		{"bad cell", testArchive(t, map[string]string{
+			manifestFile: manifest,
+			samplesFile:  `{"id": 1, "label": "a", "matrix": ["12", "00"]}` + "\n",
+		})},
+		{"ink out of range", testArchive(t, map[string]string{
+			manifestFile: manifest,
+			samplesFile:  `{"id": 1, "label": "a", "matrix": ["10", "00"], "ink": [[256, 0], [0, 0]]}` + "\n",
+		})},
+		{"wrong size", testArchive(t, map[string]string{
+			manifestFile: manifest,
+			samplesFile:  `{"id": 1, "label": "a", "matrix": ["100", "000"]}` + "\n",
+		})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadProject(tt.data); err == nil {
+				t.Error("ReadProject() succeeded")
+			}
+		})
+	}
+}
+
+func TestReadProjectIgnoresUnknownFields(t *testing.T) {
+	data := testArchive(t, map[string]string{
+		manifestFile: `{"format": "draw2matrix-project", "version": 1, "rows": 2, "cols": 2, "extra": true}`,
+		samplesFile:  `{"id": 4, "label": "a", "matrix": ["10", "01"], "extra": [1, 2]}` + "\n",
+	})
+	p, err := ReadProject(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Sample{{ID: 4, Matrix: testMatrix("10", "01"), Label: "a"}}
+	if got := p.Dataset.Samples(); !reflect.DeepEqual(got, want) {
+		t.Errorf("samples = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"golang.org/x/image/draw"
+	"image"
+)
+
+// Rasterizer converts drawings into binary matrices of a fixed size
+// together with the anti-aliased ink intensity of every cell
+type Rasterizer struct {
+	Rows int // Number of rows in the output matrix
+	Cols int // Number of columns in the output matrix
+
+	// Normalize crops the image to its ink and centres it before downscaling
+	// When nil the whole image is scaled to the matrix size
+	Normalize *Normalization
+
+	// Threshold decides which cells of the downscaled image are ink
+	Threshold Threshold
+}
+
+// NewRasterizer creates a rasterizer producing rows x cols matrices
+func NewRasterizer(rows, cols int) *Rasterizer {
+	return &Rasterizer{Rows: rows, Cols: cols}
+}
+
+// scale draws img on white paper into an image of factor x factor pixels per cell
+// normalizing it first when Normalize is set
+func (r *Rasterizer) scale(img image.Image, factor int) *image.Gray {
+	scaled := image.NewGray(image.Rect(0, 0, r.Cols*factor, r.Rows*factor))
+	draw.Draw(scaled, scaled.Rect, image.White, image.Point{}, draw.Src)
+	if r.Normalize != nil {
+		n := *r.Normalize
+		n.Margin *= factor
+		n.Place(scaled, img)
+	} else {
+		draw.CatmullRom.Scale(scaled, scaled.Rect, img, img.Bounds(), draw.Over, nil)
+	}
+	return scaled
+}
+
+// Process scales the image down to Cols x Rows pixels and binarizes it with Threshold
+// Transparent areas count as white paper. The result holds only black and white pixels
+func (r *Rasterizer) Process(img image.Image) *image.Gray {
+	switch r.Threshold.Method {
+	case CoverageThreshold, MajorityThreshold:
+		return r.Threshold.cells(r.scale(img, cellSamples), r.Rows, r.Cols)
+	}
+	final := r.scale(img, 1)
+	r.Threshold.binarize(final)
+	return final
+}
+
+// Matrix converts a processed image to a binary matrix
+// Black pixels are converted to 1, white pixels are converted to 0
+func (r *Rasterizer) Matrix(img *image.Gray) [][]int8 {
+	result := make([][]int8, r.Rows)
+	for y := 0; y < r.Rows; y++ {
+		result[y] = make([]int8, r.Cols)
+		for x := 0; x < r.Cols; x++ {
+			if img.GrayAt(x, y).Y == 0 {
+				result[y][x] = 1
+			}
+		}
+	}
+	return result
+}
+
+// Ink scales the image down like Process without binarizing it
+// and returns the ink intensity of every cell, 0 for paper and 255 for full ink
+func (r *Rasterizer) Ink(img image.Image) [][]uint8 {
+	scaled := r.scale(img, 1)
+	result := make([][]uint8, r.Rows)
+	for y := 0; y < r.Rows; y++ {
+		result[y] = make([]uint8, r.Cols)
+		for x := 0; x < r.Cols; x++ {
+			result[y][x] = 255 - scaled.GrayAt(x, y).Y
+		}
+	}
+	return result
+}
+
+// Sample converts the image into a labelled sample with its binary matrix and ink intensities
+func (r *Rasterizer) Sample(img image.Image, label string) Sample {
+	return Sample{Matrix: r.Convert(img), Ink: r.Ink(img), Label: label}
+}
+
+// Convert processes the image and returns its binary matrix
+func (r *Rasterizer) Convert(img image.Image) [][]int8 {
+	return r.Matrix(r.Process(img))
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"reflect"
+	"testing"
+)
+
+// testImage returns a white w x h image with a black rectangle
+func testImage(w, h int, ink image.Rectangle) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Rect, image.White, image.Point{}, draw.Src)
+	draw.Draw(img, ink, image.NewUniform(color.Black), image.Point{}, draw.Src)
+	return img
+}
+
+func TestRasterizerConvert(t *testing.T) {
+	// A black top-left quarter covers exactly the top-left cell
+	img := testImage(40, 40, image.Rect(0, 0, 20, 20))
+	r := NewRasterizer(2, 2)
+	r.Threshold = Threshold{Method: FixedThreshold, Level: 128}
+	if got, want := r.Convert(img), testMatrix("10", "00"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Convert() = %v, want %v", got, want)
+	}
+	ink := r.Ink(img)
+	for _, cell := range [][2]int{{0, 1}, {1, 0}, {1, 1}} {
+		if ink[cell[0]][cell[1]] >= ink[0][0] {
+			t.Errorf("Ink() = %v, want the most ink top left", ink)
+		}
+	}
+}
+
+func TestRasterizerSample(t *testing.T) {
+	img := testImage(30, 30, image.Rect(20, 20, 30, 30))
+	s := NewRasterizer(3, 3).Sample(img, "corner")
+	if s.Label != "corner" {
+		t.Errorf("Label = %q, want corner", s.Label)
+	}
+	if len(s.Matrix) != 3 || len(s.Matrix[0]) != 3 || len(s.Ink) != 3 || len(s.Ink[0]) != 3 {
+		t.Fatalf("Matrix and Ink are not 3x3: %v, %v", s.Matrix, s.Ink)
+	}
+	// The default threshold keeps every cell that is not pure paper
+	if s.Matrix[2][2] != 1 || s.Matrix[0][0] != 0 {
+		t.Errorf("Matrix = %v, want ink bottom right and paper top left", s.Matrix)
+	}
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Segment is a straight piece of a pen stroke between two points
+type Segment struct {
+	X1, Y1 float32 // Start point
+	X2, Y2 float32 // End point
+	Width  float32 // Stroke width
+}
+
+// RenderSegments draws the segments in black on a white image of the given size
+// Segment coordinates use the same units as the image, so the result does not
+// depend on where or at which scale the drawing was shown on screen.
+// Segments get round caps and anti-aliased edges.
+func RenderSegments(segments []Segment, width, height int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	for _, s := range segments {
+		drawSegment(img, s)
+	}
+	return img
+}
+
+// drawSegment darkens every pixel covered by the segment
+func drawSegment(img *image.Gray, s Segment) {
+	radius := float64(s.Width) / 2
+	if radius < 0.5 {
+		radius = 0.5
+	}
+	x1, y1, x2, y2 := float64(s.X1), float64(s.Y1), float64(s.X2), float64(s.Y2)
+
+	bounds := image.Rect(
+		int(math.Floor(math.Min(x1, x2)-radius-1)),
+		int(math.Floor(math.Min(y1, y2)-radius-1)),
+		int(math.Ceil(math.Max(x1, x2)+radius+1)),
+		int(math.Ceil(math.Max(y1, y2)+radius+1)),
+	).Intersect(img.Rect)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			d := distanceToSegment(float64(x)+0.5, float64(y)+0.5, x1, y1, x2, y2)
+			coverage := radius + 0.5 - d
+			if coverage <= 0 {
+				continue
+			}
+			if coverage > 1 {
+				coverage = 1
+			}
+			value := uint8(255 * (1 - coverage))
+			if value < img.GrayAt(x, y).Y {
+				img.SetGray(x, y, color.Gray{Y: value})
+			}
+		}
+	}
+}
+
+// distanceToSegment returns the distance from point (px, py) to the segment
+func distanceToSegment(px, py, x1, y1, x2, y2 float64) float64 {
+	dx, dy := x2-x1, y2-y1
+	lengthSquared := dx*dx + dy*dy
+	t := 0.0
+	if lengthSquared > 0 {
+		t = ((px-x1)*dx + (py-y1)*dy) / lengthSquared
+		t = math.Max(0, math.Min(1, t))
+	}
+	return math.Hypot(px-(x1+t*dx), py-(y1+t*dy))
+}
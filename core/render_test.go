@@ -0,0 +1,15 @@
+package core
+
+import "testing"
+
+func TestDrawingRender(t *testing.T) {
+	// A dot in the middle of a 10x10 canvas rendered at twice the size
+	d := Drawing{Width: 10, Height: 10, Strokes: []Stroke{{Points: []Point{{X: 5, Y: 5}}, Width: 2}}}
+	img := d.Render(20, 20)
+	if img.GrayAt(10, 10).Y != 0 {
+		t.Errorf("centre pixel = %d, want ink", img.GrayAt(10, 10).Y)
+	}
+	if img.GrayAt(0, 0).Y != 255 {
+		t.Errorf("corner pixel = %d, want paper", img.GrayAt(0, 0).Y)
+	}
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"image/png"
+	"io"
+	"strconv"
+)
+
+// WriteCSV writes the evaluation as CSV tables separated by empty lines:
+// the settings, the per-label scores, the confusion matrix with actual labels
+// as rows and the misclassified samples. Samples are numbered from 1
+func (e *Evaluation) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{
+		{"folds", "k", "distance", "seed", "samples", "accuracy"},
+		{strconv.Itoa(e.Folds), strconv.Itoa(e.K), DistanceNames[e.Distance], strconv.FormatInt(e.Seed, 10),
+			strconv.Itoa(e.Total()), formatRatio(e.Accuracy())},
+		nil,
+		{"label", "samples", "accuracy", "precision"},
+	}
+	for i, label := range e.Labels {
+		rows = append(rows, []string{label, strconv.Itoa(e.Support(i)), formatRatio(e.Recall(i)), formatRatio(e.Precision(i))})
+	}
+	rows = append(rows, nil, append([]string{"actual \\ predicted"}, e.Labels...))
+	for i, label := range e.Labels {
+		row := []string{label}
+		for _, n := range e.Confusion[i] {
+			row = append(row, strconv.Itoa(n))
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows, nil, []string{"sample", "label", "predicted", "nearest sample", "distance"})
+	for _, m := range e.Mistakes {
+		rows = append(rows, []string{strconv.Itoa(m.Index + 1), m.Actual, m.Predicted,
+			strconv.Itoa(m.Neighbour + 1), strconv.FormatFloat(m.Distance, 'f', -1, 64)})
+	}
+	// Empty records separate the tables
+	return writer.WriteAll(rows)
+}
+
+// formatRatio formats a ratio from 0 to 1 with 4 decimals
+func formatRatio(r float64) string {
+	return strconv.FormatFloat(r, 'f', 4, 64)
+}
+
+// reportTemplate is the layout of the HTML report
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(r float64) string { return fmt.Sprintf("%.1f%%", r*100) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Draw2Matrix evaluation</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th { background: #f0f0f0; }
+td.label, th.label { text-align: left; }
+img { width: 56px; height: 56px; image-rendering: pixelated; border: 1px solid #ccc; }
+</style>
+</head>
+<body>
+<h1>Draw2Matrix evaluation</h1>
+<p>{{.Folds}}-fold cross-validation of a {{.K}}-nearest neighbours classifier ({{.DistanceName}} distance, seed {{.Seed}}).</p>
+<p><strong>Accuracy: {{percent .Accuracy}}</strong> of {{.Total}} samples.</p>
+
+<h2>Labels</h2>
+<table>
+<tr><th class="label">Label</th><th>Samples</th><th>Accuracy</th><th>Precision</th></tr>
+{{range .Classes}}<tr><td class="label">{{.Label}}</td><td>{{.Support}}</td><td>{{percent .Recall}}</td><td>{{percent .Precision}}</td></tr>
+{{end}}</table>
+
+<h2>Confusion matrix</h2>
+<p>Rows are the actual labels, columns the predicted labels.</p>
+<table>
+<tr><th class="label">actual \ predicted</th>{{range .Labels}}<th>{{.}}</th>{{end}}</tr>
+{{range .Confusion}}<tr><th class="label">{{.Label}}</th>{{range .Cells}}<td style="background: {{.Color}}; color: {{.Text}}">{{.Count}}</td>{{end}}</tr>
+{{end}}</table>
+
+<h2>Most confused samples</h2>
+{{if .Mistakes}}<table>
+<tr><th>Sample</th><th class="label">Label</th><th>Nearest</th><th class="label">Predicted</th><th>Distance</th></tr>
+{{range .Mistakes}}<tr><td>#{{.Index}}<br><img src="{{.Image}}" alt="sample {{.Index}}"></td><td class="label">{{.Actual}}</td><td>#{{.Neighbour}}<br><img src="{{.NeighbourImage}}" alt="sample {{.Neighbour}}"></td><td class="label">{{.Predicted}}</td><td>{{.Distance}}</td></tr>
+{{end}}</table>{{else}}<p>No sample was misclassified.</p>{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the evaluation as a self-contained HTML page
+// d is the evaluated dataset, its samples are shown next to the mistakes
+func (e *Evaluation) WriteHTML(w io.Writer, d *Dataset) error {
+	type class struct {
+		Label             string
+		Support           int
+		Recall, Precision float64
+	}
+	type cell struct {
+		Count       int
+		Color, Text template.CSS
+	}
+	type row struct {
+		Label string
+		Cells []cell
+	}
+	type mistake struct {
+		Index, Neighbour      int
+		Actual, Predicted     string
+		Distance              string
+		Image, NeighbourImage template.URL
+	}
+	data := struct {
+		*Evaluation
+		DistanceName string
+		Classes      []class
+		Confusion    []row
+		Mistakes     []mistake
+	}{Evaluation: e, DistanceName: DistanceNames[e.Distance]}
+
+	for i, label := range e.Labels {
+		data.Classes = append(data.Classes, class{Label: label, Support: e.Support(i), Recall: e.Recall(i), Precision: e.Precision(i)})
+		r := row{Label: label}
+		for _, n := range e.Confusion[i] {
+			// Shade by the share of the actual label, so small labels stand out as much as large ones
+			v := ratio(n, e.Support(i))
+			c := HeatColor(v)
+			text := template.CSS("black")
+			if v > 0.5 {
+				text = "white"
+			}
+			r.Cells = append(r.Cells, cell{Count: n, Color: template.CSS(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)), Text: text})
+		}
+		data.Confusion = append(data.Confusion, r)
+	}
+	for _, m := range e.Mistakes {
+		image, err := sampleDataURL(d, m.Index)
+		if err != nil {
+			return err
+		}
+		neighbourImage, err := sampleDataURL(d, m.Neighbour)
+		if err != nil {
+			return err
+		}
+		data.Mistakes = append(data.Mistakes, mistake{
+			Index: m.Index + 1, Neighbour: m.Neighbour + 1, Actual: m.Actual, Predicted: m.Predicted,
+			Distance: strconv.FormatFloat(m.Distance, 'f', 2, 64), Image: image, NeighbourImage: neighbourImage,
+		})
+	}
+	return reportTemplate.Execute(w, data)
+}
+
+// sampleDataURL returns the matrix of sample i of d as a PNG data URL
+func sampleDataURL(d *Dataset, i int) (template.URL, error) {
+	s, err := d.Sample(i)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, MatrixImage(s.Matrix)); err != nil {
+		return "", err
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
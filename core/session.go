@@ -0,0 +1,116 @@
+package core
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// SessionOrder selects how a Session picks the next label
+type SessionOrder int8
+
+const (
+	// RoundRobin asks for the labels in turn
+	RoundRobin SessionOrder = iota
+	// RandomOrder asks for a random label that has not reached its quota
+	RandomOrder
+)
+
+// Session schedules which label to collect next until every label has Target samples
+// Samples already in the dataset count towards the quota, augmented ones do not
+type Session struct {
+	Labels  []string     // Labels to collect, in round robin order
+	Target  int          // Samples wanted per label
+	Order   SessionOrder // How the next label is picked
+	random  *rand.Rand
+	current int // Index of the label asked for, -1 before the first and after the last
+}
+
+// NewSession creates a session for the given labels
+// seed is used by RandomOrder
+func NewSession(labels []string, target int, order SessionOrder, seed int64) (*Session, error) {
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("no labels")
+	}
+	if target < 1 {
+		return nil, fmt.Errorf("target must be at least 1")
+	}
+	seen := map[string]bool{}
+	for _, label := range labels {
+		if label == "" {
+			return nil, fmt.Errorf("empty label")
+		}
+		if seen[label] {
+			return nil, fmt.Errorf("label %q is listed twice", label)
+		}
+		seen[label] = true
+	}
+	return &Session{
+		Labels:  append([]string(nil), labels...),
+		Target:  target,
+		Order:   order,
+		random:  rand.New(rand.NewSource(seed)),
+		current: -1,
+	}, nil
+}
+
+// Counts returns the number of original samples in d for every label of the session
+func (s *Session) Counts(d *Dataset) []int {
+	index := make(map[string]int, len(s.Labels))
+	for i, label := range s.Labels {
+		index[label] = i
+	}
+	counts := make([]int, len(s.Labels))
+	for _, sample := range d.Samples() {
+		if i, ok := index[sample.Label]; ok && !sample.Augmented() {
+			counts[i]++
+		}
+	}
+	return counts
+}
+
+// Done reports whether every label has reached the target in d
+func (s *Session) Done(d *Dataset) bool {
+	for _, count := range s.Counts(d) {
+		if count < s.Target {
+			return false
+		}
+	}
+	return true
+}
+
+// Current returns the label asked for, or an empty string when there is none
+func (s *Session) Current() string {
+	if s.current < 0 {
+		return ""
+	}
+	return s.Labels[s.current]
+}
+
+// Next picks the next label that is below the target in d and returns it
+// It returns an empty string once every quota is met
+func (s *Session) Next(d *Dataset) string {
+	counts := s.Counts(d)
+	open := make([]int, 0, len(counts))
+	for i, count := range counts {
+		if count < s.Target {
+			open = append(open, i)
+		}
+	}
+	if len(open) == 0 {
+		s.current = -1
+		return ""
+	}
+	if s.Order == RandomOrder {
+		s.current = open[s.random.Intn(len(open))]
+		return s.Current()
+	}
+	// Continue after the current label, wrapping around
+	for _, i := range open {
+		if i > s.current {
+			s.current = i
+			return s.Current()
+		}
+	}
+	s.current = open[0]
+	return s.Current()
+}
@@ -0,0 +1,190 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Names of the parts of a split dataset, used as file name suffixes
+const (
+	SplitTrain = "train"
+	SplitVal   = "val"
+	SplitTest  = "test"
+)
+
+// Split configures how a dataset is shuffled and divided before it is exported
+// The ratios are relative to their sum. Every label is divided separately, so
+// each part keeps the label proportions of the whole dataset. Augmented
+// variants always go to the part of their original, so no variant of a test
+// sample is trained on. Without a validation or test ratio the dataset is
+// exported as a single part.
+type Split struct {
+	Shuffle          bool  // Whether to shuffle the samples
+	Seed             int64 // Seed of the shuffle, the same seed gives the same order
+	Train            float64
+	Val              float64
+	Test             float64
+	ExcludeAugmented bool // Whether to leave augmented samples out
+}
+
+// Enabled reports whether the dataset is divided into several parts
+func (s Split) Enabled() bool {
+	return s.Val > 0 || s.Test > 0
+}
+
+// Active reports whether the split changes which samples are written or their order
+func (s Split) Active() bool {
+	return s.Shuffle || s.Enabled() || s.ExcludeAugmented
+}
+
+// Validate returns an error for negative ratios
+func (s Split) Validate() error {
+	if s.Enabled() && (s.Train < 0 || s.Val < 0 || s.Test < 0) {
+		return fmt.Errorf("split ratios must not be negative")
+	}
+	return nil
+}
+
+// Parts returns the names of the parts written by an enabled split
+func (s Split) Parts() []string {
+	if !s.Enabled() {
+		return []string{""}
+	}
+	parts := make([]string, 0, 3)
+	for _, p := range []struct {
+		name  string
+		ratio float64
+	}{{SplitTrain, s.Train}, {SplitVal, s.Val}, {SplitTest, s.Test}} {
+		if p.ratio > 0 {
+			parts = append(parts, p.name)
+		}
+	}
+	return parts
+}
+
+// Apply divides d into the datasets of Parts, in the same order
+// Each part keeps the label order of d so class indices agree between parts
+func (s Split) Apply(d *Dataset) ([]*Dataset, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	random := rand.New(rand.NewSource(s.Seed))
+	shuffle := func(indices []int) {
+		if s.Shuffle {
+			random.Shuffle(len(indices), func(i, j int) {
+				indices[i], indices[j] = indices[j], indices[i]
+			})
+		}
+	}
+
+	// Group every sample with the augmented variants generated from it
+	samples := d.Samples()
+	present := make(map[uint64]bool, len(samples))
+	for _, sample := range samples {
+		present[sample.ID] = true
+	}
+	variants := make(map[uint64][]int)
+	originals := make(map[string][]int)
+	for i, sample := range samples {
+		if sample.Augmented() && s.ExcludeAugmented {
+			continue
+		}
+		if sample.Augmented() && present[sample.Parent] {
+			variants[sample.Parent] = append(variants[sample.Parent], i)
+			continue
+		}
+		originals[sample.Label] = append(originals[sample.Label], i)
+	}
+
+	parts := s.Parts()
+	ratios := map[string]float64{"": 1, SplitTrain: s.Train, SplitVal: s.Val, SplitTest: s.Test}
+	total := 0.0
+	for _, part := range parts {
+		total += ratios[part]
+	}
+	assigned := make([][]int, len(parts))
+	for _, label := range d.Labels() {
+		indices := originals[label]
+		shuffle(indices)
+		// The first part takes what the rounding leaves
+		start := len(indices)
+		for p := len(parts) - 1; p > 0; p-- {
+			count := int(math.Round(float64(len(indices)) * ratios[parts[p]] / total))
+			if count > start {
+				count = start
+			}
+			assigned[p] = append(assigned[p], indices[start-count:start]...)
+			start -= count
+		}
+		assigned[0] = append(assigned[0], indices[:start]...)
+	}
+
+	result := make([]*Dataset, len(parts))
+	for p, indices := range assigned {
+		for _, i := range indices {
+			indices = append(indices, variants[samples[i].ID]...)
+		}
+		if s.Shuffle {
+			shuffle(indices)
+		} else {
+			sort.Ints(indices)
+		}
+		subset, err := d.Subset(indices)
+		if err != nil {
+			return nil, err
+		}
+		result[p] = subset
+	}
+	return result, nil
+}
+
+// SplitExporter exports every part of a split dataset with its own exporter
+type SplitExporter struct {
+	Split     Split
+	Exporters []Exporter // One exporter per part, in the order of Split.Parts
+}
+
+// NewSplitExporter creates exporters for the parts of the split
+// Their data and target file names end with an underscore and the part name
+func NewSplitExporter(format string, s ExportSettings) (SplitExporter, error) {
+	e := SplitExporter{Split: s.Split}
+	for _, part := range s.Split.Parts() {
+		settings := s
+		settings.Split = Split{}
+		if part != "" {
+			settings.DataFileName += "_" + part
+			settings.TargetFileName += "_" + part
+		}
+		exporter, err := NewExporter(format, settings)
+		if err != nil {
+			return SplitExporter{}, err
+		}
+		e.Exporters = append(e.Exporters, exporter)
+	}
+	return e, nil
+}
+
+// Files implements Exporter
+func (e SplitExporter) Files(dirPath string) []string {
+	files := make([]string, 0)
+	for _, exporter := range e.Exporters {
+		files = append(files, exporter.Files(dirPath)...)
+	}
+	return files
+}
+
+// Export implements Exporter
+func (e SplitExporter) Export(dirPath string, d *Dataset) error {
+	parts, err := e.Split.Apply(d)
+	if err != nil {
+		return err
+	}
+	for i, part := range parts {
+		if err = e.Exporters[i].Export(dirPath, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
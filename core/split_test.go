@@ -0,0 +1,135 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+// labelCounts counts the samples of every label of d
+func labelCounts(d *Dataset) map[string]int {
+	counts := make(map[string]int)
+	for _, label := range sampleLabels(d) {
+		counts[label]++
+	}
+	return counts
+}
+
+func TestSplitParts(t *testing.T) {
+	tests := []struct {
+		split Split
+		want  []string
+	}{
+		{Split{}, []string{""}},
+		{Split{Train: 100, Shuffle: true}, []string{""}},
+		{Split{Train: 80, Test: 20}, []string{SplitTrain, SplitTest}},
+		{Split{Train: 70, Val: 15, Test: 15}, []string{SplitTrain, SplitVal, SplitTest}},
+	}
+	for _, tt := range tests {
+		if got := tt.split.Parts(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v Parts() = %q, want %q", tt.split, got, tt.want)
+		}
+	}
+	if err := (Split{Train: 80, Val: -10, Test: 30}).Validate(); err == nil {
+		t.Error("Validate() of a negative ratio succeeded")
+	}
+}
+
+func TestSplitStratified(t *testing.T) {
+	labels := make([]string, 0, 15)
+	for i := 0; i < 10; i++ {
+		labels = append(labels, "a")
+	}
+	for i := 0; i < 5; i++ {
+		labels = append(labels, "b")
+	}
+	d := testDataset(t, labels...)
+	parts, err := Split{Train: 60, Val: 20, Test: 20}.Apply(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]int{{"a": 6, "b": 3}, {"a": 2, "b": 1}, {"a": 2, "b": 1}}
+	if len(parts) != len(want) {
+		t.Fatalf("Apply() returned %d parts, want %d", len(parts), len(want))
+	}
+	for i, part := range parts {
+		if got := labelCounts(part); !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("part %d counts = %v, want %v", i, got, want[i])
+		}
+		if got := part.Labels(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+			t.Errorf("part %d Labels() = %v, want [a b]", i, got)
+		}
+	}
+}
+
+func TestSplitKeepsVariantsWithOriginal(t *testing.T) {
+	d := testDataset(t, "a", "a", "a", "a")
+	for _, parent := range []uint64{1, 2, 3, 4} {
+		if err := d.AddSample(Sample{Matrix: testMatrix("11", "01"), Label: "a", Parent: parent}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	parts, err := Split{Shuffle: true, Seed: 7, Train: 50, Test: 50}.Apply(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, part := range parts {
+		ids := make(map[uint64]bool)
+		for _, s := range part.Samples() {
+			ids[s.ID] = true
+		}
+		for _, s := range part.Samples() {
+			if s.Augmented() && !ids[s.Parent] {
+				t.Errorf("part %d holds variant %d without its original %d", i, s.ID, s.Parent)
+			}
+		}
+		if part.Len() != 4 {
+			t.Errorf("part %d has %d samples, want 4", i, part.Len())
+		}
+	}
+
+	parts, err = Split{ExcludeAugmented: true}.Apply(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 1 || parts[0].Len() != 4 {
+		t.Errorf("ExcludeAugmented kept %d samples, want 4", parts[0].Len())
+	}
+}
+
+func TestSplitShuffleSeed(t *testing.T) {
+	d := testDataset(t, "a", "b", "c", "d", "e", "f", "g", "h")
+	order := func(s Split) []string {
+		parts, err := s.Apply(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sampleLabels(parts[0])
+	}
+	if got := order(Split{}); !reflect.DeepEqual(got, sampleLabels(d)) {
+		t.Errorf("order without shuffle = %v, want %v", got, sampleLabels(d))
+	}
+	first := order(Split{Shuffle: true, Seed: 1})
+	if !reflect.DeepEqual(first, order(Split{Shuffle: true, Seed: 1})) {
+		t.Error("the same seed gave different orders")
+	}
+	if reflect.DeepEqual(first, sampleLabels(d)) {
+		t.Errorf("shuffled order %v is the dataset order", first)
+	}
+	if reflect.DeepEqual(first, order(Split{Shuffle: true, Seed: 2})) {
+		t.Errorf("seeds 1 and 2 gave the same order %v", first)
+	}
+}
+
+func TestSplitExporterFiles(t *testing.T) {
+	e, err := NewSplitExporter(FormatCSV, ExportSettings{
+		DataFileName:   "data",
+		TargetFileName: "target",
+		Split:          Split{Train: 80, Test: 20},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.Files(""), []string{"data_train.csv", "data_test.csv"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Files() = %q, want %q", got, want)
+	}
+}
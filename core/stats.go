@@ -0,0 +1,189 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImbalanceRatio is the size ratio between the largest and a smaller class above which Stats warns
+const ImbalanceRatio = 2
+
+// ClassStats summarises the samples of one label
+type ClassStats struct {
+	Label     string
+	Originals int         // Samples collected or imported
+	Augmented int         // Variants generated from the originals
+	Density   float64     // Mean fraction of cells set to 1
+	Mean      [][]float64 // Mean ink of every cell from 0 (paper) to 1, Rows x Cols
+}
+
+// Count returns the number of samples of the class
+func (c ClassStats) Count() int {
+	return c.Originals + c.Augmented
+}
+
+// Duplicate is a group of original samples with identical matrices
+type Duplicate struct {
+	Indices []int    // Indices of the samples in the dataset
+	Labels  []string // Distinct labels of the samples, more than one means a conflict
+}
+
+// Conflicting reports whether the identical samples carry different labels
+func (g Duplicate) Conflicting() bool {
+	return len(g.Labels) > 1
+}
+
+// Stats summarises a dataset per class
+type Stats struct {
+	Total      int
+	Classes    []ClassStats // In the label order of the dataset
+	Duplicates []Duplicate  // In the order of their first sample
+}
+
+// Stats computes the per-class statistics of the dataset
+// Augmented samples are counted separately and left out of the duplicate search
+func (d *Dataset) Stats() Stats {
+	labels := d.Labels()
+	index := make(map[string]int, len(labels))
+	stats := Stats{Total: d.Len(), Classes: make([]ClassStats, len(labels))}
+	for i, label := range labels {
+		index[label] = i
+		stats.Classes[i] = ClassStats{Label: label, Mean: newMean(d.Rows, d.Cols)}
+	}
+
+	groups := map[string]int{} // Matrix key to index in stats.Duplicates
+	first := map[string]int{}  // Matrix key to the first sample with that matrix
+	for i, s := range d.samples {
+		class := &stats.Classes[index[s.Label]]
+		if s.Augmented() {
+			class.Augmented++
+		} else {
+			class.Originals++
+		}
+		set := 0
+		for y, row := range s.Matrix {
+			for x, v := range row {
+				if v != 0 {
+					set++
+				}
+				if s.Ink != nil {
+					class.Mean[y][x] += float64(s.Ink[y][x]) / 255
+				} else if v != 0 {
+					class.Mean[y][x]++
+				}
+			}
+		}
+		if cells := d.Rows * d.Cols; cells > 0 {
+			class.Density += float64(set) / float64(cells)
+		}
+		if s.Augmented() {
+			continue
+		}
+
+		key := matrixKey(s.Matrix)
+		j, ok := first[key]
+		if !ok {
+			first[key] = i
+			continue
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = len(stats.Duplicates)
+			groups[key] = g
+			stats.Duplicates = append(stats.Duplicates, Duplicate{Indices: []int{j}, Labels: []string{d.samples[j].Label}})
+		}
+		group := &stats.Duplicates[g]
+		group.Indices = append(group.Indices, i)
+		if !containsString(group.Labels, s.Label) {
+			group.Labels = append(group.Labels, s.Label)
+		}
+	}
+
+	for i := range stats.Classes {
+		class := &stats.Classes[i]
+		if n := float64(class.Count()); n > 0 {
+			class.Density /= n
+			for _, row := range class.Mean {
+				for x := range row {
+					row[x] /= n
+				}
+			}
+		}
+	}
+	return stats
+}
+
+// Warnings returns readable warnings about class imbalance and duplicates
+func (s Stats) Warnings() []string {
+	warnings := make([]string, 0)
+	largest := 0
+	for _, class := range s.Classes {
+		if class.Originals > largest {
+			largest = class.Originals
+		}
+	}
+	for _, class := range s.Classes {
+		if class.Originals*ImbalanceRatio < largest {
+			warnings = append(warnings, fmt.Sprintf("%q has %d samples, less than 1/%d of the largest class (%d)",
+				class.Label, class.Originals, ImbalanceRatio, largest))
+		}
+	}
+	for _, group := range s.Duplicates {
+		if group.Conflicting() {
+			warnings = append(warnings, fmt.Sprintf("%d identical samples are labelled %s",
+				len(group.Indices), strings.Join(quoteAll(group.Labels), " and ")))
+		}
+	}
+	if repeated := s.DuplicateCount(); repeated > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d samples repeat an earlier sample", repeated))
+	}
+	return warnings
+}
+
+// DuplicateCount returns the number of samples identical to an earlier one
+func (s Stats) DuplicateCount() int {
+	repeated := 0
+	for _, group := range s.Duplicates {
+		repeated += len(group.Indices) - 1
+	}
+	return repeated
+}
+
+// newMean returns a rows x cols matrix of zeros
+func newMean(rows, cols int) [][]float64 {
+	mean := make([][]float64, rows)
+	for y := range mean {
+		mean[y] = make([]float64, cols)
+	}
+	return mean
+}
+
+// matrixKey returns a string identifying the cells of a matrix
+func matrixKey(matrix [][]int8) string {
+	var b strings.Builder
+	for _, row := range matrix {
+		for _, v := range row {
+			b.WriteByte(byte(v))
+		}
+	}
+	return b.String()
+}
+
+// containsString reports whether list holds s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteAll returns the strings quoted
+func quoteAll(list []string) []string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return quoted
+}
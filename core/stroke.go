@@ -0,0 +1,66 @@
+package core
+
+import "image"
+
+// Point is a sampled pen position
+type Point struct {
+	X, Y float32 // Position in drawing coordinates
+	T    int64   // Milliseconds since the drawing was started
+}
+
+// Stroke is the ordered list of points recorded between pen down and pen up
+type Stroke struct {
+	Points []Point
+	Width  float32 // Pen width in drawing coordinates
+}
+
+// Segments splits the stroke into straight segments between consecutive points
+// A stroke with a single point becomes a dot
+func (s Stroke) Segments() []Segment {
+	if len(s.Points) == 1 {
+		p := s.Points[0]
+		return []Segment{{X1: p.X, Y1: p.Y, X2: p.X, Y2: p.Y, Width: s.Width}}
+	}
+	segments := make([]Segment, 0, len(s.Points))
+	for i := 1; i < len(s.Points); i++ {
+		a, b := s.Points[i-1], s.Points[i]
+		segments = append(segments, Segment{X1: a.X, Y1: a.Y, X2: b.X, Y2: b.Y, Width: s.Width})
+	}
+	return segments
+}
+
+// Drawing is a vector drawing made of strokes on a canvas of a known size
+type Drawing struct {
+	Width   float32 // Canvas width in drawing coordinates
+	Height  float32 // Canvas height in drawing coordinates
+	Strokes []Stroke
+}
+
+// Segments returns the segments of every stroke in drawing order
+func (d Drawing) Segments() []Segment {
+	segments := make([]Segment, 0)
+	for _, s := range d.Strokes {
+		segments = append(segments, s.Segments()...)
+	}
+	return segments
+}
+
+// Render draws the drawing on a white width x height image
+// Strokes are scaled from the canvas size to the image size, so a saved
+// drawing can be rasterized again at any resolution
+func (d Drawing) Render(width, height int) *image.Gray {
+	segments := d.Segments()
+	if d.Width > 0 && d.Height > 0 {
+		sx := float32(width) / d.Width
+		sy := float32(height) / d.Height
+		sw := (sx + sy) / 2
+		for i := range segments {
+			segments[i].X1 *= sx
+			segments[i].X2 *= sx
+			segments[i].Y1 *= sy
+			segments[i].Y2 *= sy
+			segments[i].Width *= sw
+		}
+	}
+	return RenderSegments(segments, width, height)
+}
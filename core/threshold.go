@@ -0,0 +1,140 @@
+package core
+
+import (
+	"fmt"
+	"image"
+)
+
+// ThresholdMethod selects how the Rasterizer decides which cells are ink
+type ThresholdMethod int8
+
+const (
+	// AnyInk marks every downscaled pixel that is not pure white as ink
+	AnyInk ThresholdMethod = iota
+	// FixedThreshold marks downscaled pixels darker than Threshold.Level as ink
+	FixedThreshold
+	// OtsuThreshold picks the level for every image with Otsu's method
+	OtsuThreshold
+	// CoverageThreshold marks cells whose area is at least Threshold.Coverage ink
+	CoverageThreshold
+	// MajorityThreshold marks cells whose area is mostly ink
+	MajorityThreshold
+)
+
+// ThresholdMethodNames names the methods in the order of their values
+var ThresholdMethodNames = []string{"any", "fixed", "otsu", "coverage", "majority"}
+
+// ParseThresholdMethod returns the method with the given name
+func ParseThresholdMethod(name string) (ThresholdMethod, error) {
+	for i, n := range ThresholdMethodNames {
+		if n == name {
+			return ThresholdMethod(i), nil
+		}
+	}
+	return AnyInk, fmt.Errorf("unknown threshold method %q", name)
+}
+
+// cellSamples is the number of samples per cell and axis used by the cell based methods
+const cellSamples = 8
+
+// inkLevel is the gray level below which a sample of a cell counts as ink
+const inkLevel = 128
+
+// Threshold configures the binarization of the Rasterizer
+// The zero value keeps every pixel that is not pure white
+type Threshold struct {
+	Method   ThresholdMethod
+	Level    uint8   // Gray level below which a pixel is ink, for FixedThreshold
+	Coverage float64 // Fraction of a cell that must be ink, for CoverageThreshold
+}
+
+// level returns the gray level below which a pixel of img is ink
+func (t Threshold) level(img *image.Gray) uint8 {
+	switch t.Method {
+	case FixedThreshold:
+		return t.Level
+	case OtsuThreshold:
+		return OtsuLevel(img)
+	}
+	return 255
+}
+
+// binarize turns the downscaled img into black ink on white paper
+func (t Threshold) binarize(img *image.Gray) {
+	level := t.level(img)
+	for i, y := range img.Pix {
+		if y < level {
+			img.Pix[i] = 0
+		} else {
+			img.Pix[i] = 255
+		}
+	}
+}
+
+// cells reduces the supersampled img to one black or white pixel per cell
+// by the share of ink samples in each cell
+func (t Threshold) cells(img *image.Gray, rows, cols int) *image.Gray {
+	result := image.NewGray(image.Rect(0, 0, cols, rows))
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			ink := 0
+			for y := row * cellSamples; y < (row+1)*cellSamples; y++ {
+				for x := col * cellSamples; x < (col+1)*cellSamples; x++ {
+					if img.GrayAt(x, y).Y < inkLevel {
+						ink++
+					}
+				}
+			}
+			share := float64(ink) / (cellSamples * cellSamples)
+			isInk := ink > 0 && share >= t.Coverage
+			if t.Method == MajorityThreshold {
+				isInk = share > 0.5
+			}
+			if isInk {
+				result.Pix[row*result.Stride+col] = 0
+			} else {
+				result.Pix[row*result.Stride+col] = 255
+			}
+		}
+	}
+	return result
+}
+
+// OtsuLevel returns the gray level separating ink from paper in img
+// chosen to maximise the variance between the two classes. Pixels darker
+// than the level are ink. An image of a single gray level is split at the middle
+func OtsuLevel(img *image.Gray) uint8 {
+	var histogram [256]int
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			histogram[img.GrayAt(x, y).Y]++
+		}
+	}
+	total := b.Dx() * b.Dy()
+	sum := 0.0
+	for level, count := range histogram {
+		sum += float64(level * count)
+	}
+
+	best, bestVariance := -1, 0.0
+	darkCount, darkSum := 0, 0.0
+	for level := 0; level < 255; level++ {
+		darkCount += histogram[level]
+		darkSum += float64(level * histogram[level])
+		lightCount := total - darkCount
+		if darkCount == 0 || lightCount == 0 {
+			continue
+		}
+		darkMean := darkSum / float64(darkCount)
+		lightMean := (sum - darkSum) / float64(lightCount)
+		variance := float64(darkCount) * float64(lightCount) * (darkMean - lightMean) * (darkMean - lightMean)
+		if best < 0 || variance > bestVariance {
+			best, bestVariance = level, variance
+		}
+	}
+	if best < 0 {
+		return inkLevel
+	}
+	return uint8(best + 1)
+}
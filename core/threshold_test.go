@@ -0,0 +1,68 @@
+package core
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"reflect"
+	"testing"
+)
+
+func TestParseThresholdMethod(t *testing.T) {
+	for i, name := range ThresholdMethodNames {
+		method, err := ParseThresholdMethod(name)
+		if err != nil || method != ThresholdMethod(i) {
+			t.Errorf("ParseThresholdMethod(%q) = %d, %v, want %d", name, method, err, i)
+		}
+	}
+	if _, err := ParseThresholdMethod("Otsu"); err == nil {
+		t.Error("ParseThresholdMethod() of an unknown name succeeded")
+	}
+}
+
+func TestOtsuLevel(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	draw.Draw(img, img.Rect, image.NewUniform(color.Gray{Y: 200}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 4, 1), image.NewUniform(color.Gray{Y: 50}), image.Point{}, draw.Src)
+	if level := OtsuLevel(img); level != 51 {
+		t.Errorf("OtsuLevel() = %d, want 51", level)
+	}
+
+	draw.Draw(img, img.Rect, image.NewUniform(color.Gray{Y: 200}), image.Point{}, draw.Src)
+	if level := OtsuLevel(img); level != inkLevel {
+		t.Errorf("OtsuLevel() of a plain image = %d, want %d", level, inkLevel)
+	}
+}
+
+func TestThresholdMethods(t *testing.T) {
+	// Gray ink lighter than the fixed level of 64
+	gray := image.NewGray(image.Rect(0, 0, 32, 32))
+	draw.Draw(gray, gray.Rect, image.NewUniform(color.Gray{Y: 100}), image.Point{}, draw.Src)
+	// 37.5% ink in the top-left cell and 75% in the top-right cell
+	cells := testImage(32, 32, image.Rect(0, 0, 16, 6))
+	draw.Draw(cells, image.Rect(16, 0, 32, 12), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	tests := []struct {
+		name      string
+		img       image.Image
+		threshold Threshold
+		want      [][]int8
+	}{
+		{"any ink", gray, Threshold{}, testMatrix("11", "11")},
+		{"fixed below", gray, Threshold{Method: FixedThreshold, Level: 128}, testMatrix("11", "11")},
+		{"fixed above", gray, Threshold{Method: FixedThreshold, Level: 64}, testMatrix("00", "00")},
+		{"low coverage", cells, Threshold{Method: CoverageThreshold, Coverage: 0.2}, testMatrix("11", "00")},
+		{"high coverage", cells, Threshold{Method: CoverageThreshold, Coverage: 0.6}, testMatrix("01", "00")},
+		{"full coverage", cells, Threshold{Method: CoverageThreshold, Coverage: 0.9}, testMatrix("00", "00")},
+		{"majority", cells, Threshold{Method: MajorityThreshold}, testMatrix("01", "00")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRasterizer(2, 2)
+			r.Threshold = tt.threshold
+			if got := r.Convert(tt.img); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"fmt"
+	"math"
+)
+
+// ValueMode selects what the exported value of a cell means
+type ValueMode int8
+
+const (
+	// BinaryValues writes the thresholded matrix, 0 or 1
+	BinaryValues ValueMode = iota
+	// GrayValues writes the ink intensity from 0 to 255
+	GrayValues
+	// UnitValues writes the ink intensity from 0.0 to 1.0
+	UnitValues
+	// LevelValues writes the ink intensity quantised to 0 .. Levels-1
+	LevelValues
+)
+
+// ValueModeNames names the modes in the order of their values
+var ValueModeNames = []string{"binary", "gray", "unit", "levels"}
+
+// ParseValueMode returns the mode with the given name
+func ParseValueMode(name string) (ValueMode, error) {
+	for i, n := range ValueModeNames {
+		if n == name {
+			return ValueMode(i), nil
+		}
+	}
+	return BinaryValues, fmt.Errorf("unknown value mode %q", name)
+}
+
+// Values selects the cell values written by the exporters
+// The zero value writes the binary matrices
+type Values struct {
+	Mode   ValueMode
+	Levels int // Number of levels for LevelValues, from 2 to 256
+}
+
+// Validate returns an error when the number of levels is out of range
+func (v Values) Validate() error {
+	if v.Mode == LevelValues && (v.Levels < 2 || v.Levels > 256) {
+		return fmt.Errorf("levels must be between 2 and 256, got %d", v.Levels)
+	}
+	return nil
+}
+
+// Integer reports whether every value is a whole number from 0 to 255
+func (v Values) Integer() bool {
+	return v.Mode != UnitValues
+}
+
+// Max returns the value of a cell full of ink
+func (v Values) Max() float64 {
+	switch v.Mode {
+	case GrayValues:
+		return 255
+	case LevelValues:
+		return float64(v.Levels - 1)
+	}
+	return 1
+}
+
+// cell converts an ink intensity from 0 to 255 into the value of a cell
+func (v Values) cell(ink uint8) float64 {
+	switch v.Mode {
+	case UnitValues:
+		return float64(ink) / 255
+	case LevelValues:
+		return math.Round(float64(ink) * float64(v.Levels-1) / 255)
+	}
+	return float64(ink)
+}
+
+// Matrix returns the cell values of a sample
+// Samples without ink intensities count their binary cells as full ink or paper
+func (v Values) Matrix(s Sample) [][]float64 {
+	result := make([][]float64, len(s.Matrix))
+	for y, row := range s.Matrix {
+		result[y] = make([]float64, len(row))
+		for x, b := range row {
+			switch {
+			case v.Mode == BinaryValues:
+				result[y][x] = float64(b)
+			case s.Ink != nil:
+				result[y][x] = v.cell(s.Ink[y][x])
+			case b != 0:
+				result[y][x] = v.Max()
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseValueMode(t *testing.T) {
+	for i, name := range ValueModeNames {
+		mode, err := ParseValueMode(name)
+		if err != nil || mode != ValueMode(i) {
+			t.Errorf("ParseValueMode(%q) = %d, %v, want %d", name, mode, err, i)
+		}
+	}
+	if _, err := ParseValueMode("float"); err == nil {
+		t.Error("ParseValueMode() of an unknown name succeeded")
+	}
+}
+
+func TestValuesValidate(t *testing.T) {
+	tests := []struct {
+		values Values
+		ok     bool
+	}{
+		{Values{}, true},
+		{Values{Mode: GrayValues, Levels: 1}, true},
+		{Values{Mode: LevelValues, Levels: 2}, true},
+		{Values{Mode: LevelValues, Levels: 256}, true},
+		{Values{Mode: LevelValues, Levels: 1}, false},
+		{Values{Mode: LevelValues, Levels: 257}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.values.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%+v Validate() = %v, want ok %v", tt.values, err, tt.ok)
+		}
+	}
+}
+
+func TestValuesMatrix(t *testing.T) {
+	withInk := Sample{Matrix: testMatrix("10", "01"), Ink: [][]uint8{{255, 51}, {0, 128}}}
+	withoutInk := Sample{Matrix: testMatrix("10", "01")}
+	tests := []struct {
+		name    string
+		values  Values
+		sample  Sample
+		want    [][]float64
+		integer bool
+	}{
+		{"binary", Values{}, withInk, [][]float64{{1, 0}, {0, 1}}, true},
+		{"gray", Values{Mode: GrayValues}, withInk, [][]float64{{255, 51}, {0, 128}}, true},
+		{"unit", Values{Mode: UnitValues}, withInk, [][]float64{{1, 0.2}, {0, 128.0 / 255}}, false},
+		{"levels", Values{Mode: LevelValues, Levels: 4}, withInk, [][]float64{{3, 1}, {0, 2}}, true},
+		{"gray without ink", Values{Mode: GrayValues}, withoutInk, [][]float64{{255, 0}, {0, 255}}, true},
+		{"levels without ink", Values{Mode: LevelValues, Levels: 4}, withoutInk, [][]float64{{3, 0}, {0, 3}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.values.Matrix(tt.sample); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Matrix() = %v, want %v", got, tt.want)
+			}
+			if got := tt.values.Integer(); got != tt.integer {
+				t.Errorf("Integer() = %v, want %v", got, tt.integer)
+			}
+		})
+	}
+}
@@ -2,25 +2,54 @@
 package main
 
 import (
-	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
+	"github.com/ehsan-torabi/Draw2Matrix/core"
 	"image/color"
 	"image/png"
 	"os"
 	"path/filepath"
+	"time"
 )
 
-// PrevPos stores the previous mouse position for drawing continuous lines
-var PrevPos fyne.Position = fyne.NewPos(0, 0)
+// defaultStrokeWidth is the pen width used for new strokes
+const defaultStrokeWidth = 8
+
+// changeInterval is the shortest time between two OnChanged calls while drawing
+// Every call converts the whole drawing, so mouse moves are coalesced
+const changeInterval = 50 * time.Millisecond
+
+// Tool selects what dragging on the paint widget does
+type Tool int8
+
+const (
+	// PenTool draws new strokes
+	PenTool Tool = iota
+	// EraserTool removes or splits the strokes it touches
+	EraserTool
+)
 
 // PaintWidget represents a custom widget for drawing
-// It extends the base widget and maintains a collection of lines
+// It records the drawing as strokes and keeps the canvas lines derived from them
 type PaintWidget struct {
 	widget.BaseWidget
-	lines []*canvas.Line // Collection of lines drawn on the widget
+	strokes []core.Stroke   // Strokes in drawing order
+	drawing bool            // Whether a stroke is in progress
+	start   time.Time       // Time of the first point of the drawing
+	lines   []*canvas.Line  // Collection of lines drawn on the widget
+	undo    [][]core.Stroke // Earlier drawing states for Undo
+	redo    [][]core.Stroke // Undone drawing states for Redo
+
+	tool     Tool          // Active tool
+	penWidth float32       // Pen width in pixels, used when penCells is zero
+	penCells float32       // Pen width in matrix cells, zero for a fixed pixel width
+	prevPos  fyne.Position // Previous eraser position
+	erased   bool          // Whether the current eraser drag changed the drawing
+
+	OnChanged   func()      // Called after the strokes changed, at most once per changeInterval
+	changeTimer *time.Timer // Pending OnChanged call, nil when none is pending
 }
 
 // CreateRenderer implements the Widget interface, creating a new renderer for the paint widget
@@ -45,58 +74,241 @@ func (p *PaintWidget) getLineObjects() []fyne.CanvasObject {
 	return objects
 }
 
+// newLine creates the canvas line shown for a stroke segment
+func newLine(s core.Segment) *canvas.Line {
+	line := canvas.NewLine(color.Black)
+	line.StrokeWidth = s.Width
+	line.Position1 = fyne.NewPos(s.X1, s.Y1)
+	line.Position2 = fyne.NewPos(s.X2, s.Y2)
+	return line
+}
+
+// rebuildLines derives the canvas lines from the recorded strokes
+func (p *PaintWidget) rebuildLines() {
+	p.lines = make([]*canvas.Line, 0)
+	for _, stroke := range p.strokes {
+		for _, s := range stroke.Segments() {
+			p.lines = append(p.lines, newLine(s))
+		}
+	}
+}
+
+// addPoint appends a point to the current stroke and draws the new segment
+func (p *PaintWidget) addPoint(pos fyne.Position) {
+	if len(p.strokes) == 0 {
+		p.start = time.Now()
+	}
+	point := core.Point{X: pos.X, Y: pos.Y, T: time.Since(p.start).Milliseconds()}
+	if !p.drawing {
+		p.pushHistory()
+		p.strokes = append(p.strokes, core.Stroke{Width: p.strokeWidth()})
+		p.drawing = true
+	}
+	stroke := &p.strokes[len(p.strokes)-1]
+	stroke.Points = append(stroke.Points, point)
+	if n := len(stroke.Points); n > 1 {
+		prev := stroke.Points[n-2]
+		p.lines = append(p.lines, newLine(core.Segment{X1: prev.X, Y1: prev.Y, X2: point.X, Y2: point.Y, Width: stroke.Width}))
+	}
+	p.changed()
+}
+
+// eraseTo erases along the path from the previous eraser position to pos
+// The first change of an eraser drag is recorded as one Undo step
+func (p *PaintWidget) eraseTo(pos fyne.Position) {
+	if !p.drawing {
+		p.drawing = true
+		p.erased = false
+		p.prevPos = pos
+	}
+	strokes, changed := core.EraseAlong(p.strokes, p.prevPos.X, p.prevPos.Y, pos.X, pos.Y, p.strokeWidth())
+	p.prevPos = pos
+	if !changed {
+		return
+	}
+	if !p.erased {
+		p.pushHistory()
+		p.erased = true
+	}
+	p.strokes = strokes
+	p.rebuildLines()
+	p.changed()
+}
+
 // MouseDown handles mouse button press events
+// Starts a new stroke or eraser drag at the pressed position
 func (p *PaintWidget) MouseDown(ev *desktop.MouseEvent) {
-	PrevPos = ev.Position
+	if ev.Button != desktop.MouseButtonPrimary {
+		return
+	}
+	p.drawing = false
+	if p.tool == EraserTool {
+		p.eraseTo(ev.Position)
+		return
+	}
+	p.addPoint(ev.Position)
 }
 
 // MouseMoved handles mouse movement events
-// Creates new line segments when the primary button is pressed
+// Extends the current stroke or erases when the primary button is pressed
 func (p *PaintWidget) MouseMoved(ev *desktop.MouseEvent) {
-	if ev.Button == desktop.MouseButtonPrimary {
-		newPos := ev.Position
-		line := canvas.NewLine(color.Black)
-		line.StrokeWidth = 8
-		line.Position1 = PrevPos
-		line.Position2 = newPos
-		p.lines = append(p.lines, line)
-		PrevPos = ev.Position
-		p.Refresh()
+	if ev.Button != desktop.MouseButtonPrimary {
+		return
+	}
+	if p.tool == EraserTool {
+		p.eraseTo(ev.Position)
+		return
 	}
+	p.addPoint(ev.Position)
 }
 
 // MouseIn handles mouse enter events
 func (p *PaintWidget) MouseIn(ev *desktop.MouseEvent) {}
 
 // MouseOut handles mouse leave events
-func (p *PaintWidget) MouseOut() {}
+// Ends the current stroke so re-entering the widget starts a new one
+func (p *PaintWidget) MouseOut() {
+	p.drawing = false
+}
 
 // MouseUp handles mouse button release events
-func (p *PaintWidget) MouseUp(ev *desktop.MouseEvent) {}
-
-// PrintMatrix outputs the current drawing as a binary matrix
-// If flat is true, outputs as a flattened array
-func (p *PaintWidget) PrintMatrix(w fyne.Window, flat bool) {
-	img := captureAndProcessImage(w, p)
-	mat := image2BinaryMatrix(img)
-	fmt.Println()
-	if flat {
-		fmt.Println(ToFlattenMatrix(mat))
-	} else {
-		for _, line := range mat {
-			fmt.Println(line)
-		}
+// Ends the current stroke, a stroke without movement is kept as a dot
+func (p *PaintWidget) MouseUp(ev *desktop.MouseEvent) {
+	p.drawing = false
+	p.flushChanged()
+}
+
+// changed redraws the widget and schedules OnChanged
+// Changes within changeInterval are reported by a single call
+func (p *PaintWidget) changed() {
+	p.Refresh()
+	if p.OnChanged == nil || p.changeTimer != nil {
+		return
+	}
+	p.changeTimer = time.AfterFunc(changeInterval, func() {
+		fyne.Do(p.notifyChanged)
+	})
+}
+
+// flushChanged calls a pending OnChanged at once, so the finished stroke is shown without delay
+func (p *PaintWidget) flushChanged() {
+	if p.changeTimer != nil && p.changeTimer.Stop() {
+		p.notifyChanged()
 	}
 }
 
+// notifyChanged calls OnChanged for the changes since it was scheduled
+func (p *PaintWidget) notifyChanged() {
+	p.changeTimer = nil
+	if p.OnChanged != nil {
+		p.OnChanged()
+	}
+}
+
+// SetTool selects the tool used when dragging on the widget
+func (p *PaintWidget) SetTool(tool Tool) {
+	p.tool = tool
+	p.drawing = false
+}
+
+// SetPenWidth sets a fixed pen width in pixels for new strokes
+func (p *PaintWidget) SetPenWidth(width float32) {
+	p.penWidth = width
+	p.penCells = 0
+}
+
+// SetPenWidthInCells sets the pen width as a multiple of one matrix cell
+// so the stroke thickness follows the matrix size in the settings
+func (p *PaintWidget) SetPenWidthInCells(cells float32) {
+	p.penCells = cells
+}
+
+// strokeWidth returns the pen width in pixels for a new stroke
+func (p *PaintWidget) strokeWidth() float32 {
+	rows, cols := matrixSize()
+	if p.penCells == 0 || rows <= 0 || cols <= 0 {
+		return p.penWidth
+	}
+	size := p.canvasSize()
+	cell := size.Width / float32(cols)
+	if h := size.Height / float32(rows); h < cell {
+		cell = h
+	}
+	return cell * p.penCells
+}
+
+// pushHistory saves the current drawing state for Undo and drops the Redo states
+func (p *PaintWidget) pushHistory() {
+	p.undo = append(p.undo, append([]core.Stroke(nil), p.strokes...))
+	p.redo = nil
+}
+
+// Undo restores the drawing state before the last stroke or clear
+func (p *PaintWidget) Undo() {
+	if len(p.undo) == 0 {
+		return
+	}
+	p.redo = append(p.redo, p.strokes)
+	p.strokes = p.undo[len(p.undo)-1]
+	p.undo = p.undo[:len(p.undo)-1]
+	p.drawing = false
+	p.rebuildLines()
+	p.changed()
+}
+
+// Redo reapplies the last undone stroke or clear
+func (p *PaintWidget) Redo() {
+	if len(p.redo) == 0 {
+		return
+	}
+	p.undo = append(p.undo, p.strokes)
+	p.strokes = p.redo[len(p.redo)-1]
+	p.redo = p.redo[:len(p.redo)-1]
+	p.drawing = false
+	p.rebuildLines()
+	p.changed()
+}
+
+// SetStrokes replaces the current drawing with the given strokes
+func (p *PaintWidget) SetStrokes(strokes []core.Stroke) {
+	p.pushHistory()
+	p.strokes = append(make([]core.Stroke, 0, len(strokes)), strokes...)
+	p.drawing = false
+	p.rebuildLines()
+	p.changed()
+}
+
+// canvasSize returns the drawing area size, before layout the default paint size
+func (p *PaintWidget) canvasSize() fyne.Size {
+	size := p.Size()
+	if size.Width < 1 || size.Height < 1 {
+		return defaultPaintSize
+	}
+	return size
+}
+
+// Drawing returns the recorded strokes together with the size of the widget
+func (p *PaintWidget) Drawing() core.Drawing {
+	size := p.canvasSize()
+	strokes := make([]core.Stroke, len(p.strokes))
+	for i, stroke := range p.strokes {
+		strokes[i] = core.Stroke{Points: append([]core.Point(nil), stroke.Points...), Width: stroke.Width}
+	}
+	return core.Drawing{Width: size.Width, Height: size.Height, Strokes: strokes}
+}
+
 // GetMatrix returns the current drawing as a binary matrix
-func (p *PaintWidget) GetMatrix(w fyne.Window) [][]int8 {
-	img := captureAndProcessImage(w, p)
-	return image2BinaryMatrix(img)
+func (p *PaintWidget) GetMatrix() [][]int8 {
+	return currentRasterizer().Matrix(processDrawing(p))
+}
+
+// GetInk returns the ink intensity of every matrix cell of the current drawing
+func (p *PaintWidget) GetInk() [][]uint8 {
+	return currentRasterizer().Ink(renderDrawing(p))
 }
 
 // ExportToPNG saves the current drawing as a PNG file
-func (p *PaintWidget) ExportToPNG(w fyne.Window, filename string) error {
+func (p *PaintWidget) ExportToPNG(filename string) error {
 	// Create output directory if it doesn't exist
 	wd, _ := os.Getwd()
 	err := os.Mkdir(filepath.Join(wd, "output"), os.ModePerm)
@@ -112,14 +324,20 @@ func (p *PaintWidget) ExportToPNG(w fyne.Window, filename string) error {
 	defer file.Close()
 
 	// Process and save image
-	result := captureAndProcessImage(w, p)
+	result := processDrawing(p)
 	return png.Encode(file, result)
 }
 
-// Clear removes all drawn lines from the widget
+// Clear removes all strokes from the widget
+// Clearing can be undone like a stroke
 func (p *PaintWidget) Clear() {
+	if len(p.strokes) > 0 {
+		p.pushHistory()
+	}
+	p.strokes = make([]core.Stroke, 0)
+	p.drawing = false
 	p.lines = []*canvas.Line{}
-	p.Refresh()
+	p.changed()
 }
 
 // paintRenderer implements the fyne.WidgetRenderer interface
@@ -157,7 +375,9 @@ func (r *paintRenderer) Destroy() {}
 // NewPaintWidget creates and initializes a new paint widget
 func NewPaintWidget() *PaintWidget {
 	p := &PaintWidget{
-		lines: make([]*canvas.Line, 0),
+		strokes:  make([]core.Stroke, 0),
+		lines:    make([]*canvas.Line, 0),
+		penWidth: defaultStrokeWidth,
 	}
 	p.ExtendBaseWidget(p)
 	return p
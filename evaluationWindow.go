@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/ehsan-torabi/Draw2Matrix/core"
+	"image/color"
+	"io"
+	"log"
+	"strconv"
+)
+
+// defaultFolds is the default number of cross-validation folds
+const defaultFolds = 5
+
+// EvaluationWindow shows the cross-validation report of a dataset: accuracy per
+// label, the confusion matrix as a heatmap and the most confused sample pairs
+type EvaluationWindow struct {
+	window     fyne.Window
+	evaluation *core.Evaluation
+	dataset    *core.Dataset // Copy of the evaluated samples, the indices of the report refer to it
+	sample     *canvas.Image
+	neighbour  *canvas.Image
+	caption    *widget.Label
+}
+
+// evaluateOperation asks for the cross-validation settings and evaluates the current dataset
+func evaluateOperation() {
+	if CurrentDataset.Len() == 0 {
+		dialog.ShowError(fmt.Errorf("Please first add at least 1 label"), Application.mainWindow)
+		return
+	}
+	foldsEntry := widget.NewEntry()
+	foldsEntry.SetText(strconv.Itoa(defaultFolds))
+	kEntry := widget.NewEntry()
+	kEntry.SetText(strconv.Itoa(Options.PredictK))
+	distance := widget.NewSelect(distanceOptions, nil)
+	distance.SetSelected(distanceOptions[Options.PredictDistance])
+	seedEntry := widget.NewEntry()
+	seedEntry.SetText(strconv.Itoa(Options.SplitSeed))
+	items := []*widget.FormItem{
+		widget.NewFormItem("Folds", foldsEntry),
+		widget.NewFormItem("Neighbours (k)", kEntry),
+		widget.NewFormItem("Distance", distance),
+		widget.NewFormItem("Seed", seedEntry),
+	}
+	dialog.ShowForm("Evaluate dataset", "Evaluate", "Cancel", items, func(b bool) {
+		if !b {
+			return
+		}
+		cv := core.CrossValidation{Distance: core.Distance(distance.SelectedIndex())}
+		var err error
+		if cv.Folds, err = strconv.Atoi(foldsEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("folds: enter number"), Application.mainWindow)
+			return
+		}
+		if cv.K, err = strconv.Atoi(kEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("neighbours: enter number"), Application.mainWindow)
+			return
+		}
+		if cv.Seed, err = strconv.ParseInt(seedEntry.Text, 10, 64); err != nil {
+			dialog.ShowError(fmt.Errorf("seed: enter number"), Application.mainWindow)
+			return
+		}
+		if err = cv.Validate(); err != nil {
+			dialog.ShowError(err, Application.mainWindow)
+			return
+		}
+		evaluateDataset(cv)
+	}, Application.mainWindow)
+}
+
+// evaluateDataset runs the cross-validation in the background and opens its report
+func evaluateDataset(cv core.CrossValidation) {
+	all := make([]int, CurrentDataset.Len())
+	for i := range all {
+		all[i] = i
+	}
+	dataset, err := CurrentDataset.Subset(all)
+	if err != nil {
+		dialog.ShowError(err, Application.mainWindow)
+		return
+	}
+
+	progress := widget.NewProgressBar()
+	progress.Max = float64(cv.Folds)
+	progressDialog := dialog.NewCustomWithoutButtons("Evaluating dataset", progress, Application.mainWindow)
+	progressDialog.Show()
+	go func() {
+		evaluation, err := cv.Run(dataset, func(done int) {
+			fyne.Do(func() {
+				progress.SetValue(float64(done))
+			})
+		})
+		fyne.Do(func() {
+			progressDialog.Hide()
+			if err != nil {
+				dialog.ShowError(err, Application.mainWindow)
+				return
+			}
+			NewEvaluationWindow(mainApp, evaluation, dataset).Show()
+		})
+	}()
+}
+
+// NewEvaluationWindow creates the report window of an evaluation of dataset
+func NewEvaluationWindow(a fyne.App, e *core.Evaluation, dataset *core.Dataset) *EvaluationWindow {
+	w := &EvaluationWindow{window: a.NewWindow("Evaluation"), evaluation: e, dataset: dataset}
+	summary := widget.NewLabel(fmt.Sprintf("Accuracy %.1f%% of %d samples (%d folds, k = %d, %s distance)",
+		e.Accuracy()*100, e.Total(), e.Folds, e.K, distanceOptions[e.Distance]))
+	exportCSV := widget.NewButtonWithIcon("Export CSV", theme.DocumentSaveIcon(), func() {
+		w.export("evaluation.csv", e.WriteCSV)
+	})
+	exportHTML := widget.NewButtonWithIcon("Export HTML", theme.DocumentSaveIcon(), func() {
+		w.export("evaluation.html", func(writer io.Writer) error {
+			return e.WriteHTML(writer, dataset)
+		})
+	})
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Labels", w.labelsTable()),
+		container.NewTabItem("Confusion Matrix", w.confusionHeatmap()),
+		container.NewTabItem(fmt.Sprintf("Confused Samples (%d)", len(e.Mistakes)), w.mistakesView()),
+	)
+	top := container.NewBorder(nil, nil, nil, container.NewHBox(exportCSV, exportHTML), summary)
+	w.window.SetContent(container.NewBorder(container.NewPadded(top), nil, nil, nil, tabs))
+	w.window.Resize(fyne.NewSize(720, 520))
+	return w
+}
+
+// Show opens the report window
+func (w *EvaluationWindow) Show() {
+	w.window.Show()
+}
+
+// labelsTable lists the number of samples, accuracy and precision of every label
+func (w *EvaluationWindow) labelsTable() fyne.CanvasObject {
+	e := w.evaluation
+	cells := []fyne.CanvasObject{
+		widget.NewLabelWithStyle("Label", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Samples", fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Accuracy", fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Precision", fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}),
+	}
+	for i, label := range e.Labels {
+		cells = append(cells,
+			widget.NewLabel(label),
+			widget.NewLabelWithStyle(strconv.Itoa(e.Support(i)), fyne.TextAlignTrailing, fyne.TextStyle{}),
+			widget.NewLabelWithStyle(fmt.Sprintf("%.1f%%", e.Recall(i)*100), fyne.TextAlignTrailing, fyne.TextStyle{}),
+			widget.NewLabelWithStyle(fmt.Sprintf("%.1f%%", e.Precision(i)*100), fyne.TextAlignTrailing, fyne.TextStyle{}),
+		)
+	}
+	return container.NewVScroll(container.NewGridWithColumns(4, cells...))
+}
+
+// confusionHeatmap draws the confusion matrix with actual labels as rows
+// Cells are shaded by their share of the actual label
+func (w *EvaluationWindow) confusionHeatmap() fyne.CanvasObject {
+	e := w.evaluation
+	cells := []fyne.CanvasObject{widget.NewLabel("actual \\ predicted")}
+	for _, label := range e.Labels {
+		cells = append(cells, widget.NewLabelWithStyle(label, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}))
+	}
+	for i, label := range e.Labels {
+		cells = append(cells, widget.NewLabelWithStyle(label, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		for _, n := range e.Confusion[i] {
+			share := 0.0
+			if support := e.Support(i); support > 0 {
+				share = float64(n) / float64(support)
+			}
+			text := canvas.NewText(strconv.Itoa(n), color.Black)
+			if share > 0.5 {
+				text.Color = color.White
+			}
+			text.Alignment = fyne.TextAlignCenter
+			cell := canvas.NewRectangle(core.HeatColor(share))
+			cell.SetMinSize(fyne.NewSize(40, 32))
+			cells = append(cells, container.NewStack(cell, container.NewCenter(text)))
+		}
+	}
+	return container.NewScroll(container.NewGridWithColumns(len(e.Labels)+1, cells...))
+}
+
+// mistakesView lists the misclassified samples, the closest pairs first
+// Selecting one shows it next to the training sample that misled it
+func (w *EvaluationWindow) mistakesView() fyne.CanvasObject {
+	e := w.evaluation
+	w.sample = canvas.NewImageFromImage(nil)
+	w.neighbour = canvas.NewImageFromImage(nil)
+	for _, img := range []*canvas.Image{w.sample, w.neighbour} {
+		img.FillMode = canvas.ImageFillContain
+		img.ScaleMode = canvas.ImageScalePixels
+		img.SetMinSize(fyne.NewSize(140, 140))
+	}
+	w.caption = widget.NewLabel("Select a sample to inspect it.")
+	list := widget.NewList(
+		func() int {
+			return len(e.Mistakes)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			m := e.Mistakes[id]
+			obj.(*widget.Label).SetText(fmt.Sprintf("#%d %s predicted as %s", m.Index+1, m.Actual, m.Predicted))
+		},
+	)
+	list.OnSelected = w.inspect
+	inspector := container.NewBorder(nil, w.caption, nil, nil, container.NewGridWithColumns(2, w.sample, w.neighbour))
+	split := container.NewHSplit(list, inspector)
+	split.Offset = 0.4
+	return split
+}
+
+// inspect shows mistake id next to its nearest training sample of the predicted label
+func (w *EvaluationWindow) inspect(id widget.ListItemID) {
+	m := w.evaluation.Mistakes[id]
+	sample, err := w.dataset.Sample(m.Index)
+	if err != nil {
+		return
+	}
+	neighbour, err := w.dataset.Sample(m.Neighbour)
+	if err != nil {
+		return
+	}
+	w.sample.Image = core.MatrixImage(sample.Matrix)
+	w.sample.Refresh()
+	w.neighbour.Image = core.MatrixImage(neighbour.Matrix)
+	w.neighbour.Refresh()
+	w.caption.SetText(fmt.Sprintf("Left: sample #%d labelled %s. Right: its nearest %s, sample #%d (distance %.2f).",
+		m.Index+1, m.Actual, m.Predicted, m.Neighbour+1, m.Distance))
+}
+
+// export asks for a file and writes the report to it with write
+func (w *EvaluationWindow) export(name string, write func(io.Writer) error) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		err = write(writer)
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			log.Println(err)
+			dialog.ShowError(fmt.Errorf("error exporting report"), w.window)
+		}
+	}, w.window)
+	save.SetFileName(name)
+	save.Show()
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/ehsan-torabi/Draw2Matrix/core"
+)
+
+// allLabels is the filter entry that shows every sample
+const allLabels = "All labels"
+
+// Gallery shows the collected samples as thumbnails and lets the user
+// delete, relabel or reopen them
+type Gallery struct {
+	window     fyne.Window
+	filter     *widget.Select
+	grid       *widget.GridWrap
+	info       *widget.Label
+	deleteBtn  *widget.Button
+	relabel    *widget.Button
+	reopen     *widget.Button
+	indices    []int  // Dataset indices of the shown samples
+	selected   int    // Dataset index of the selected sample, -1 if none
+	selectedID uint64 // ID of the selected sample, it keeps the selection when indices shift
+}
+
+// NewGallery creates the gallery window for the current dataset
+func NewGallery(a fyne.App) *Gallery {
+	g := &Gallery{window: a.NewWindow("Samples"), selected: -1}
+	g.filter = widget.NewSelect(nil, func(string) {
+		g.selected = -1
+		g.grid.UnselectAll()
+		g.Refresh()
+	})
+	g.grid = widget.NewGridWrap(
+		func() int {
+			return len(g.indices)
+		},
+		func() fyne.CanvasObject {
+			thumbnail := canvas.NewImageFromImage(nil)
+			thumbnail.FillMode = canvas.ImageFillContain
+			thumbnail.ScaleMode = canvas.ImageScalePixels
+			thumbnail.SetMinSize(fyne.NewSize(72, 72))
+			return container.NewBorder(nil, widget.NewLabel(""), nil, nil, thumbnail)
+		},
+		func(id widget.GridWrapItemID, obj fyne.CanvasObject) {
+			sample, err := CurrentDataset.Sample(g.indices[id])
+			if err != nil {
+				return
+			}
+			item := obj.(*fyne.Container)
+			if sample.Augmented() {
+				item.Objects[1].(*widget.Label).SetText(sample.Label + " (aug)")
+			} else {
+				item.Objects[1].(*widget.Label).SetText(sample.Label)
+			}
+			thumbnail := item.Objects[0].(*canvas.Image)
+			thumbnail.Image = core.MatrixImage(sample.Matrix)
+			thumbnail.Refresh()
+		},
+	)
+	g.grid.OnSelected = func(id widget.GridWrapItemID) {
+		g.selected = g.indices[id]
+		if sample, err := CurrentDataset.Sample(g.selected); err == nil {
+			g.selectedID = sample.ID
+		}
+		g.updateActions()
+	}
+	g.grid.OnUnselected = func(widget.GridWrapItemID) {
+		g.selected = -1
+		g.updateActions()
+	}
+
+	g.info = widget.NewLabel("")
+	g.deleteBtn = widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), g.deleteSelected)
+	g.deleteBtn.Importance = widget.DangerImportance
+	g.relabel = widget.NewButtonWithIcon("Relabel", theme.DocumentCreateIcon(), g.relabelSelected)
+	g.reopen = widget.NewButtonWithIcon("Open in Paint", theme.WindowMaximizeIcon(), g.reopenSelected)
+
+	top := container.NewBorder(nil, nil, widget.NewLabel("Filter:"), g.info, g.filter)
+	actions := container.NewGridWithColumns(3, g.deleteBtn, g.relabel, g.reopen)
+	g.window.SetContent(container.NewBorder(container.NewPadded(top), container.NewPadded(actions), nil, nil, g.grid))
+	g.window.Resize(fyne.NewSize(640, 520))
+	g.window.SetOnClosed(func() {
+		if Application.gallery == g {
+			Application.gallery = nil
+		}
+	})
+	g.Refresh()
+	return g
+}
+
+// Show opens the gallery window
+func (g *Gallery) Show() {
+	g.window.Show()
+}
+
+// Refresh reloads the labels and thumbnails from the current dataset
+func (g *Gallery) Refresh() {
+	options := append([]string{allLabels}, CurrentDataset.Labels()...)
+	current := g.filter.Selected
+	g.filter.Options = options
+	if current == "" || !containsString(options, current) {
+		current = allLabels
+	}
+	g.filter.Selected = current
+	g.filter.Refresh()
+
+	label := current
+	if label == allLabels {
+		label = ""
+	}
+	g.indices = CurrentDataset.Indices(label)
+	// Follow the selected sample to its new position, or drop the selection
+	// when it was deleted or no longer matches the filter
+	position := -1
+	if g.selected != -1 {
+		for i, index := range g.indices {
+			if sample, err := CurrentDataset.Sample(index); err == nil && sample.ID == g.selectedID {
+				position = i
+				break
+			}
+		}
+	}
+	if position == -1 {
+		g.selected = -1
+		g.grid.UnselectAll()
+	} else {
+		g.selected = g.indices[position]
+		g.grid.Select(position)
+	}
+	g.info.SetText(fmt.Sprintf("%d of %d samples", len(g.indices), CurrentDataset.Len()))
+	g.grid.Refresh()
+	g.updateActions()
+}
+
+// updateActions enables the sample actions only while a sample is selected
+func (g *Gallery) updateActions() {
+	for _, btn := range []*widget.Button{g.deleteBtn, g.relabel, g.reopen} {
+		if g.selected == -1 {
+			btn.Disable()
+		} else {
+			btn.Enable()
+		}
+	}
+}
+
+// deleteSelected removes the selected sample after confirmation
+func (g *Gallery) deleteSelected() {
+	index := g.selected
+	dialog.ShowConfirm("Delete sample", "Are you sure you want to delete this sample?", func(b bool) {
+		if !b {
+			return
+		}
+		if err := CurrentDataset.Remove(index); err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		g.selected = -1
+		datasetChanged()
+		writeSnapshot()
+	}, g.window)
+}
+
+// relabelSelected asks for a new label for the selected sample
+func (g *Gallery) relabelSelected() {
+	index := g.selected
+	sample, err := CurrentDataset.Sample(index)
+	if err != nil {
+		return
+	}
+	entry := widget.NewEntry()
+	entry.SetText(sample.Label)
+	entry.Validator = labelValidator
+	dialog.ShowForm("Relabel sample", "Save", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Label", entry),
+	}, func(b bool) {
+		if !b {
+			return
+		}
+		if err := CurrentDataset.Relabel(index, entry.Text); err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		datasetChanged()
+		writeSnapshot()
+	}, g.window)
+}
+
+// reopenSelected loads the strokes and label of the selected sample into the paint window
+func (g *Gallery) reopenSelected() {
+	sample, err := CurrentDataset.Sample(g.selected)
+	if err != nil {
+		return
+	}
+	if sample.Drawing == nil {
+		dialog.ShowError(fmt.Errorf("this sample has no stroke data"), g.window)
+		return
+	}
+	Application.paintObject.SetStrokes(sample.Drawing.Strokes)
+	input.SetText(sample.Label)
+	openPaintWindowOperation()
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -8,35 +8,71 @@ package main
 import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
-	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/ehsan-torabi/Draw2Matrix/core"
 	"strconv"
 )
 
 // Options stores the global application settings
 var Options struct {
-	FlatMatrix           bool // Whether to flatten the matrix when saving
-	MatlabSaveFormat     bool // Whether to save in MATLAB compatible format
-	DotMFileWithVariable bool // Whether to save array in variable for matlab in .m file
-	MatrixCol            int  // Number of columns in the output matrix
-	MatrixRow            int  // Number of rows in the output matrix
-	SettingsSaved        bool // Whether settings have been Saved and locked
-	OneHotEncodingSave   bool // Whether to save target to one-hot-encoding format
+	FlatMatrix           bool   // Whether to flatten the matrix when saving
+	CSVSaveFormat        bool   // Whether to save in CSV format
+	MatlabSaveFormat     bool   // Whether to save in MATLAB compatible format
+	NumpySaveFormat      bool   // Whether to save NumPy .npy arrays
+	NumpyBundle          bool   // Whether to bundle the NumPy arrays into one .npz file
+	MatFileSaveFormat    bool   // Whether to save a binary MATLAB .mat file
+	MatFileCompress      bool   // Whether to compress the variables in the .mat file
+	IDXSaveFormat        bool   // Whether to save MNIST style IDX files
+	DotMFileWithVariable bool   // Whether to save array in variable for matlab in .m file
+	MatrixCol            int    // Number of columns in the output matrix
+	MatrixRow            int    // Number of rows in the output matrix
+	SettingsSaved        bool   // Whether settings have been Saved and locked
+	OneHotEncodingSave   bool   // Whether to save target to one-hot-encoding format
+	NormalizeInk         bool   // Whether to crop drawings to their ink and centre them
+	NormalizeMargin      int    // Empty cells kept around the normalized ink
+	CenterOfMass         bool   // Whether to centre the ink by its centre of mass instead of its bounding box
+	ThresholdMethod      int    // Binarization method, a core.ThresholdMethod
+	ThresholdLevel       int    // Gray level below which a pixel is ink for the fixed threshold
+	ThresholdCoverage    int    // Percentage of a cell that must be ink for the coverage threshold
+	ValueMode            int    // Exported cell values, a core.ValueMode
+	ValueLevels          int    // Number of levels for the N levels output
+	ShuffleExport        bool   // Whether to shuffle the samples when saving
+	SplitSeed            int    // Seed of the shuffle
+	SplitExport          bool   // Whether to split the samples into train/val/test files
+	SplitTrain           int    // Percentage of each label in the training files
+	SplitVal             int    // Percentage of each label in the validation files
+	SplitTest            int    // Percentage of each label in the test files
+	ExcludeAugmented     bool   // Whether to leave augmented samples out when saving
+	SavePath             string // Directory the dataset files are saved in
+	DataFileName         string // Name of the data files
+	TargetFileName       string // Name of the target files
+	Predict              bool   // Whether to classify the drawing with k-NN while drawing
+	PredictK             int    // Number of neighbours of the k-NN classifier
+	PredictDistance      int    // Distance of the k-NN classifier, a core.Distance
 }
 
+// builtinOptions holds the built-in defaults of Options, set by main
+var builtinOptions = Options
+
 var (
-	mainApp     = app.New()
+	mainApp     fyne.App
 	Application struct {
 		mainWindow  fyne.Window
 		paintWindow fyne.Window
 		paintObject *PaintWidget
+		gallery     *Gallery
+		thresholds  *ThresholdPreview
+		session     *SessionPanel
+		stats       *StatsWindow
+		classifier  *core.KNN // Trained on the current dataset, nil when it must be trained again
 	}
 )
 
 // main initializes and runs the Draw2Matrix application
 func main() {
 	// Initialize application and main window
+	mainApp = app.NewWithID(appID)
 	window := mainApp.NewWindow("Draw2Matrix")
 	Application.mainWindow = window
 
@@ -44,32 +80,69 @@ func main() {
 
 	// Initialize default application options
 	Options.FlatMatrix = false       // Default to flat matrix output
+	Options.CSVSaveFormat = true     // Default to CSV format
 	Options.MatlabSaveFormat = false // Default to MATLAB format
 	Options.MatrixRow = 20
 	Options.MatrixCol = 20
+	Options.NormalizeMargin = 2
+	Options.ThresholdLevel = defaultThresholdLevel
+	Options.ThresholdCoverage = defaultThresholdCoverage
+	Options.ValueLevels = defaultValueLevels
+	Options.SplitSeed = defaultSplitSeed
+	Options.SplitTrain, Options.SplitVal, Options.SplitTest = defaultSplitTrain, defaultSplitVal, defaultSplitTest
+	Options.PredictK = defaultPredictK
+	Options.DataFileName = "data"
+	Options.TargetFileName = "target"
+	builtinOptions = Options
 
 	// Initialize UI components
+	content := newMainContent()
 	paint := NewPaintWidget()
+	paint.OnChanged = refreshPreviews
 	paintWindow := NewPaintWindow(mainApp, paint)
 	Application.paintWindow = paintWindow
 	Application.paintObject = paint
 	refreshBtn.Importance = widget.HighImportance
 	savePath.SetPlaceHolder("Directory path For save file")
+	savePath.OnChanged = func(s string) {
+		Options.SavePath = s
+	}
 	dataFileEntry.SetPlaceHolder("Data file name")
-	dataFileEntry.SetText("data")
+	dataFileEntry.OnChanged = func(s string) {
+		Options.DataFileName = s
+	}
+	dataFileEntry.SetText(Options.DataFileName)
 
 	targetFileEntry.SetPlaceHolder("Target file name")
-	targetFileEntry.SetText("target")
-	targetFileEntry.Disable()
+	targetFileEntry.OnChanged = func(s string) {
+		Options.TargetFileName = s
+	}
+	targetFileEntry.SetText(Options.TargetFileName)
 
 	input.SetPlaceHolder("Enter Label")
 	input.Validator = labelValidator
 
 	exportBtn.Importance = widget.MediumImportance
 
+	csvSaveCheck.Checked = true
 	matlabSaveCheck.Checked = false
 	flatMatrixCheck.Checked = false
-	dotMFileWithVariableCheck.Disable()
+	npzBundleCheck.Disable()
+	matCompressCheck.Disable()
+	updateFormatWidgets()
+	levelsInput.SetPlaceHolder("Levels")
+	levelsInput.SetText(strconv.Itoa(Options.ValueLevels))
+	levelsInput.Validator = levelsValidator
+	valuesSelect.SetSelected(valueOptions[0])
+	seedInput.SetPlaceHolder("Seed")
+	seedInput.Validator = seedValidator
+	trainInput.SetPlaceHolder("Train %")
+	trainInput.Validator = ratioValidator(&Options.SplitTrain)
+	valInput.SetPlaceHolder("Val %")
+	valInput.Validator = ratioValidator(&Options.SplitVal)
+	testInput.SetPlaceHolder("Test %")
+	testInput.Validator = ratioValidator(&Options.SplitTest)
+	updateSplitWidgets()
 
 	rowInput.SetPlaceHolder("Rows")
 	rowInput.SetText(strconv.Itoa(Options.MatrixRow))
@@ -78,28 +151,42 @@ func main() {
 	colInput.SetPlaceHolder("Columns")
 	colInput.SetText(strconv.Itoa(Options.MatrixCol))
 	colInput.Validator = colValidator
+	marginInput.SetText(strconv.Itoa(Options.NormalizeMargin))
+	marginInput.Validator = marginValidator
+	centerSelect.SetSelected(centerOptions[0])
+	thresholdLevelInput.SetPlaceHolder("Level (1-255)")
+	thresholdLevelInput.SetText(strconv.Itoa(Options.ThresholdLevel))
+	thresholdLevelInput.Validator = thresholdLevelValidator
+	coverageInput.SetPlaceHolder("Coverage %")
+	coverageInput.SetText(strconv.Itoa(Options.ThresholdCoverage))
+	coverageInput.Validator = coverageValidator
+	thresholdSelect.SetSelected(thresholdOptions[0])
+	predictKInput.SetPlaceHolder("k")
+	predictKInput.SetText(strconv.Itoa(Options.PredictK))
+	predictKInput.Validator = predictKValidator
+	distanceSelect.SetSelected(distanceOptions[0])
+	predictKInput.Disable()
+	distanceSelect.Disable()
 	counterLabel.SetText("0")
+	sessionContainer.Hide()
 	addBtn.Importance = widget.MediumImportance
 	addAndClearPaintBtn.Importance = widget.DangerImportance
 
-	// Main content with padding
-	content := container.NewBorder(
-		nil,
-		container.NewPadded(bottomContainer),
-		nil,
-		nil,
-		nil,
-	)
-
 	// Set window content and size
 	window.SetContent(content)
+	// Replace the built-in defaults with the ones saved in the preferences
+	loadDefaults()
+	addUndoShortcuts(window.Canvas(), paint)
+	window.SetMainMenu(mainMenu())
 	window.SetMaster()
-	window.Resize(fyne.NewSize(800, 500))
+	window.Resize(fyne.NewSize(1000, 500))
 	window.CenterOnScreen()
 
 	// Configure application lifecycle handlers
-	// OnStarted: Initialize matrix display
+	// OnStarted: Show the matrix of the empty drawing in the preview and offer to restore an unsaved session
 	mainApp.Lifecycle().SetOnStarted(onStartedApplication)
+	// OnStopped: Remember the settings if asked to and keep an unsaved session for recovery
+	mainApp.Lifecycle().SetOnStopped(onStoppedApplication)
 
 	// Start the application
 	window.Show()
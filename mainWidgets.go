@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
@@ -10,80 +11,234 @@ import (
 	"image/color"
 )
 
+// Widgets of the main window used by the operations, created by newMainContent
 var (
-	countValue       = binding.NewString()
-	statusLabel      = canvas.NewText("start", color.Black)
-	counterLabelText = widget.NewLabel("count: ")
-	counterLabel     = widget.NewLabelWithData(countValue)
-	refreshBtn       = widget.NewButtonWithIcon("Clear Paint", theme.DeleteIcon(), func() {
+	statusLabel               *canvas.Text
+	counterLabel              *widget.Label
+	refreshBtn                *widget.Button
+	savePath                  *widget.Entry
+	dataFileEntry             *widget.Entry
+	targetFileEntry           *widget.Entry
+	saveBtn                   *widget.Button
+	input                     *widget.Entry
+	exportBtn                 *widget.Button
+	flatMatrixCheck           *widget.Check
+	csvSaveCheck              *widget.Check
+	matlabSaveCheck           *widget.Check
+	dotMFileWithVariableCheck *widget.Check
+	oneHotEncodingSaveCheck   *widget.Check
+	numpySaveCheck            *widget.Check
+	npzBundleCheck            *widget.Check
+	matFileSaveCheck          *widget.Check
+	matCompressCheck          *widget.Check
+	idxSaveCheck              *widget.Check
+	valuesSelect              *widget.Select
+	levelsInput               *widget.Entry
+	shuffleCheck              *widget.Check
+	skipAugmentedCheck        *widget.Check
+	seedInput                 *widget.Entry
+	splitCheck                *widget.Check
+	trainInput                *widget.Entry
+	valInput                  *widget.Entry
+	testInput                 *widget.Entry
+	normalizeCheck            *widget.Check
+	marginInput               *widget.Entry
+	centerSelect              *widget.Select
+	thresholdSelect           *widget.Select
+	thresholdLevelInput       *widget.Entry
+	coverageInput             *widget.Entry
+	colInput                  *widget.Entry
+	rowInput                  *widget.Entry
+	addBtn                    *widget.Button
+	addAndClearPaintBtn       *widget.Button
+	matrixPreview             *MatrixPreview
+	previewInfo               *widget.Label
+	predictCheck              *widget.Check
+	predictKInput             *widget.Entry
+	distanceSelect            *widget.Select
+	predictionLabel           *widget.Label
+)
+
+// centerOptions lists the choices of centerSelect, in the order of core.Centering
+var centerOptions = []string{"Bounding box", "Centre of mass"}
+
+// thresholdOptions lists the choices of thresholdSelect, in the order of core.ThresholdMethod
+var thresholdOptions = []string{"Any ink", "Fixed level", "Otsu", "Cell coverage", "Cell majority"}
+
+// distanceOptions lists the choices of distanceSelect, in the order of core.Distance
+var distanceOptions = []string{"Hamming", "Euclidean"}
+
+// valueOptions lists the choices of valuesSelect, in the order of core.ValueMode
+var valueOptions = []string{"Binary (0/1)", "Grayscale (0-255)", "Grayscale (0.0-1.0)", "N levels"}
+
+// newMainContent creates the widgets of the main window and returns its content
+// with the live matrix preview on the right. Theme icons need the running
+// application, so it must be called after the application is created.
+func newMainContent() fyne.CanvasObject {
+	countValue := binding.NewString()
+	statusLabel = canvas.NewText("start", color.Black)
+	counterLabelText := widget.NewLabel("count: ")
+	counterLabel = widget.NewLabelWithData(countValue)
+	refreshBtn = widget.NewButtonWithIcon("Clear Paint", theme.DeleteIcon(), func() {
 		Application.paintObject.Clear()
 	})
-	savePath        = widget.NewEntry()
-	dataFileEntry   = widget.NewEntry()
+	savePath = widget.NewEntry()
+	dataFileEntry = widget.NewEntry()
 	targetFileEntry = widget.NewEntry()
-	openPaint       = widget.NewButtonWithIcon("OpenPaint", theme.WindowMaximizeIcon(), openPaintWindowOperation)
-	changePath      = widget.NewButtonWithIcon("Browse", theme.FolderIcon(), browseOperation)
-	saveBtn         = widget.NewButtonWithIcon("Save file", theme.DocumentSaveIcon(), exportFileOperation)
-	input           = widget.NewEntry()
-	exportBtn       = widget.NewButtonWithIcon("Export PNG", theme.FileImageIcon(), expertPNGOperation)
+	openPaint := widget.NewButtonWithIcon("OpenPaint", theme.WindowMaximizeIcon(), openPaintWindowOperation)
+	changePath := widget.NewButtonWithIcon("Browse", theme.FolderIcon(), browseOperation)
+	saveBtn = widget.NewButtonWithIcon("Save file", theme.DocumentSaveIcon(), exportFileOperation)
+	input = widget.NewEntry()
+	exportBtn = widget.NewButtonWithIcon("Export PNG", theme.FileImageIcon(), expertPNGOperation)
 	flatMatrixCheck = widget.NewCheck("Flat Matrix", func(b bool) {
 		Options.FlatMatrix = b
 	})
-	matlabSaveCheck           = widget.NewCheck("Matlab Save Format", matlabSaveCheckBoxFunction)
+	csvSaveCheck = widget.NewCheck("CSV Save Format", csvSaveCheckBoxFunction)
+	matlabSaveCheck = widget.NewCheck("Matlab Save Format", matlabSaveCheckBoxFunction)
 	dotMFileWithVariableCheck = widget.NewCheck(".m file save", DotMFileWithVariableCheck)
-	oneHotEncodingSaveCheck   = widget.NewCheck("One Hot Encoding Save", oneHotEncodingCheckBoxFunction)
-	colInput                  = widget.NewEntry()
-	rowInput                  = widget.NewEntry()
-	addBtn                    = widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), addButtonFunction)
-	addAndClearPaintBtn       = widget.NewButtonWithIcon("Add & Clear Paint", theme.ContentCutIcon(), func() {
+	oneHotEncodingSaveCheck = widget.NewCheck("One Hot Encoding Save", oneHotEncodingCheckBoxFunction)
+	numpySaveCheck = widget.NewCheck("NumPy Save Format", numpySaveCheckBoxFunction)
+	npzBundleCheck = widget.NewCheck(".npz bundle", npzBundleCheckBoxFunction)
+	matFileSaveCheck = widget.NewCheck("MAT-file Save Format", matFileSaveCheckBoxFunction)
+	matCompressCheck = widget.NewCheck("Compress .mat", func(b bool) {
+		Options.MatFileCompress = b
+	})
+	idxSaveCheck = widget.NewCheck("IDX Save Format", idxSaveCheckBoxFunction)
+	valuesSelect = widget.NewSelect(valueOptions, valuesSelectFunction)
+	levelsInput = widget.NewEntry()
+	shuffleCheck = widget.NewCheck("Shuffle", shuffleCheckBoxFunction)
+	skipAugmentedCheck = widget.NewCheck("Skip Augmented", func(b bool) {
+		Options.ExcludeAugmented = b
+	})
+	seedInput = widget.NewEntry()
+	splitCheck = widget.NewCheck("Train/Val/Test Split", splitCheckBoxFunction)
+	trainInput = widget.NewEntry()
+	valInput = widget.NewEntry()
+	testInput = widget.NewEntry()
+	normalizeCheck = widget.NewCheck("Crop & Centre Ink", func(b bool) {
+		Options.NormalizeInk = b
+		refreshPreviews()
+	})
+	marginInput = widget.NewEntry()
+	centerSelect = widget.NewSelect(centerOptions, centerSelectFunction)
+	thresholdSelect = widget.NewSelect(thresholdOptions, thresholdSelectFunction)
+	thresholdLevelInput = widget.NewEntry()
+	coverageInput = widget.NewEntry()
+	compareThresholds := widget.NewButtonWithIcon("Compare", theme.VisibilityIcon(), openThresholdPreviewOperation)
+	colInput = widget.NewEntry()
+	rowInput = widget.NewEntry()
+	addBtn = widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), addButtonFunction)
+	addAndClearPaintBtn = widget.NewButtonWithIcon("Add & Clear Paint", theme.ContentCutIcon(), func() {
 		addButtonFunction()
 		Application.paintObject.Clear()
 	})
-	saveOptionsBtn = widget.NewButtonWithIcon("Save Settings", theme.SettingsIcon(), func() {
+	saveOptionsBtn := widget.NewButtonWithIcon("Save Settings", theme.SettingsIcon(), func() {
 		applyProjectSetting(true)
 	})
-	resetProjectBtn = widget.NewButtonWithIcon("Reset Project", theme.ContentClearIcon(), resetProjectSetting)
-	toolbar         = widget.NewToolbar(
+	resetProjectBtn := widget.NewButtonWithIcon("Reset Project", theme.ContentClearIcon(), resetProjectSetting)
+	matrixPreview = NewMatrixPreview()
+	previewInfo = widget.NewLabel("")
+	predictCheck = widget.NewCheck("Live Prediction (k-NN)", predictCheckBoxFunction)
+	predictKInput = widget.NewEntry()
+	distanceSelect = widget.NewSelect(distanceOptions, distanceSelectFunction)
+	predictionLabel = widget.NewLabel("")
+	toolbar := widget.NewToolbar(
 		widget.NewToolbarAction(theme.DocumentSaveIcon(), saveProjectFileFunction),
 		widget.NewToolbarAction(theme.ContentUndoIcon(), loadProjectFileFunction),
+		widget.NewToolbarAction(theme.GridIcon(), openGalleryOperation),
+		widget.NewToolbarAction(theme.ListIcon(), openStatsOperation),
 		widget.NewToolbarAction(theme.InfoIcon(), aboutBtn))
-)
+	sessionContainer = container.NewVBox()
 
-// Layout containers
-var (
-	settingsContainer = container.NewVBox(
+	// Layout containers
+	settingsContainer := container.NewVBox(
 		openPaint,
 		widget.NewLabel("Matrix Settings:"),
 		container.NewGridWithColumns(2, rowInput, colInput),
-		container.NewGridWithColumns(4, flatMatrixCheck, matlabSaveCheck, dotMFileWithVariableCheck, oneHotEncodingSaveCheck),
+		container.NewGridWithColumns(4, normalizeCheck, widget.NewLabel("Margin (cells):"), marginInput, centerSelect),
+		container.NewBorder(nil, nil, widget.NewLabel("Threshold:"), nil,
+			container.NewGridWithColumns(4, thresholdSelect, thresholdLevelInput, coverageInput, compareThresholds)),
 		container.NewGridWithColumns(2, resetProjectBtn, saveOptionsBtn),
 	)
 
-	pathContainer = container.NewVBox(
+	pathContainer := container.NewVBox(
 		container.NewGridWithColumns(2, savePath, changePath),
 		dataFileEntry,
 		targetFileEntry,
 	)
-	actionContainer = container.NewVBox(
+	formatContainer := container.NewVBox(
+		widget.NewLabel("Save Formats:"),
+		container.NewGridWithColumns(2, csvSaveCheck, flatMatrixCheck),
+		container.NewGridWithColumns(3, matlabSaveCheck, dotMFileWithVariableCheck, oneHotEncodingSaveCheck),
+		container.NewGridWithColumns(2, numpySaveCheck, npzBundleCheck),
+		container.NewGridWithColumns(2, matFileSaveCheck, matCompressCheck),
+		container.NewGridWithColumns(2, idxSaveCheck),
+		container.NewBorder(nil, nil, widget.NewLabel("Values:"), nil, container.NewGridWithColumns(2, valuesSelect, levelsInput)),
+		container.NewGridWithColumns(3, shuffleCheck, seedInput, skipAugmentedCheck),
+		container.NewGridWithColumns(4, splitCheck, trainInput, valInput, testInput),
+	)
+	actionContainer := container.NewVBox(
 		widget.NewLabel("Actions:"),
 		container.NewGridWithColumns(2, refreshBtn, exportBtn),
+		formatContainer,
 		pathContainer, saveBtn,
 	)
-	statusContainer = container.NewHBox(
+	statusContainer := container.NewHBox(
 		container.NewPadded(container.NewGridWithColumns(2, counterLabelText, counterLabel)),
 		layout.NewSpacer(),
 		container.NewPadded(statusLabel),
 	)
 
-	labelContainer = container.NewVBox(
+	labelContainer := container.NewVBox(
+		sessionContainer,
 		widget.NewLabel("Label:"),
 		container.NewBorder(nil, statusContainer, addBtn, addAndClearPaintBtn, input),
 	)
 
-	bottomContainer = container.NewVBox(
+	previewContainer := container.NewBorder(widget.NewLabel("Matrix Preview:"),
+		container.NewVBox(
+			previewInfo,
+			predictCheck,
+			container.NewBorder(nil, nil, widget.NewLabel("k:"), distanceSelect, predictKInput),
+			predictionLabel,
+		),
+		nil, nil, matrixPreview)
+
+	bottomContainer := container.NewVBox(
 		container.NewPadded(toolbar),
 		container.NewPadded(settingsContainer),
 		container.NewPadded(actionContainer),
 		container.NewPadded(labelContainer),
 	)
-)
+
+	return container.NewBorder(
+		nil,
+		nil,
+		nil,
+		container.NewPadded(previewContainer),
+		container.NewBorder(nil, container.NewPadded(bottomContainer), nil, nil, nil),
+	)
+}
+
+// mainMenu creates the menu of the main window
+func mainMenu() *fyne.MainMenu {
+	return fyne.NewMainMenu(
+		fyne.NewMenu("File",
+			fyne.NewMenuItem("Import images...", importImagesOperation),
+			fyne.NewMenuItem("Import IDX...", importIDXOperation),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Preferences...", preferencesOperation),
+		),
+		fyne.NewMenu("Dataset",
+			fyne.NewMenuItem("Augment...", augmentOperation),
+			fyne.NewMenuItem("Remove augmented samples", removeAugmentedOperation),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Statistics", openStatsOperation),
+			fyne.NewMenuItem("Evaluate dataset...", evaluateOperation),
+		),
+		fyne.NewMenu("Session",
+			fyne.NewMenuItem("Start session...", startSessionOperation),
+			fyne.NewMenuItem("End session", endSession),
+		),
+	)
+}
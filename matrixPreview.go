@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+	"image/color"
+)
+
+// Colors of the matrix preview
+var (
+	previewInk   = color.Black
+	previewPaper = color.White
+	previewGrid  = color.Gray{Y: 200}
+)
+
+// MatrixPreview shows a binary matrix as a grid of square cells
+// Cells set to 1 are black, so the annotator sees exactly what Add will store
+type MatrixPreview struct {
+	widget.BaseWidget
+	matrix [][]int8 // Matrix shown, rows x cols
+}
+
+// NewMatrixPreview creates an empty matrix preview
+func NewMatrixPreview() *MatrixPreview {
+	m := &MatrixPreview{}
+	m.ExtendBaseWidget(m)
+	return m
+}
+
+// SetMatrix replaces the shown matrix
+func (m *MatrixPreview) SetMatrix(matrix [][]int8) {
+	m.matrix = matrix
+	m.Refresh()
+}
+
+// CreateRenderer implements the Widget interface
+func (m *MatrixPreview) CreateRenderer() fyne.WidgetRenderer {
+	r := &matrixPreviewRenderer{widget: m}
+	r.raster = canvas.NewRasterWithPixels(r.pixel)
+	return r
+}
+
+// matrixPreviewRenderer draws the preview grid pixel by pixel
+type matrixPreviewRenderer struct {
+	widget *MatrixPreview
+	raster *canvas.Raster
+}
+
+// pixel returns the color of pixel x, y when the grid is drawn into w x h pixels
+// The grid keeps square cells and is centred, with a line between the cells
+func (r *matrixPreviewRenderer) pixel(x, y, w, h int) color.Color {
+	matrix := r.widget.matrix
+	if len(matrix) == 0 || len(matrix[0]) == 0 {
+		return previewPaper
+	}
+	rows, cols := len(matrix), len(matrix[0])
+	cell := w / cols
+	if h/rows < cell {
+		cell = h / rows
+	}
+	if cell < 1 {
+		cell = 1
+	}
+	x -= (w - cell*cols) / 2
+	y -= (h - cell*rows) / 2
+	if x < 0 || y < 0 || x >= cell*cols || y >= cell*rows {
+		return color.Transparent
+	}
+	if cell > 3 && (x%cell == 0 || y%cell == 0) {
+		return previewGrid
+	}
+	if matrix[y/cell][x/cell] != 0 {
+		return previewInk
+	}
+	return previewPaper
+}
+
+// Layout implements WidgetRenderer interface
+func (r *matrixPreviewRenderer) Layout(size fyne.Size) {
+	r.raster.Resize(size)
+}
+
+// MinSize implements WidgetRenderer interface
+func (r *matrixPreviewRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(160, 160)
+}
+
+// Refresh implements WidgetRenderer interface
+func (r *matrixPreviewRenderer) Refresh() {
+	canvas.Refresh(r.raster)
+}
+
+// Objects implements WidgetRenderer interface
+func (r *matrixPreviewRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.raster}
+}
+
+// Destroy implements WidgetRenderer interface
+func (r *matrixPreviewRenderer) Destroy() {}
@@ -3,12 +3,93 @@ package main
 import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
 )
 
+// penWidthOptions lists the pen widths offered in the paint window
+// A width is either fixed in pixels or relative to one matrix cell
+var penWidthOptions = []struct {
+	name   string
+	pixels float32
+	cells  float32
+}{
+	{"2 px", 2, 0},
+	{"4 px", 4, 0},
+	{"8 px", 8, 0},
+	{"16 px", 16, 0},
+	{"1/2 cell", 0, 0.5},
+	{"1 cell", 0, 1},
+	{"2 cells", 0, 2},
+}
+
 func NewPaintWindow(a fyne.App, paintObject *PaintWidget) fyne.Window {
 	paintWindow := a.NewWindow("Paint")
-	paintWindow.SetContent(container.NewPadded(paintObject))
+	paintToolbar := widget.NewToolbar(
+		widget.NewToolbarAction(theme.ContentUndoIcon(), paintObject.Undo),
+		widget.NewToolbarAction(theme.ContentRedoIcon(), paintObject.Redo),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.DeleteIcon(), paintObject.Clear),
+	)
+	top := container.NewVBox(paintToolbar, newToolPalette(paintObject))
+	paintWindow.SetContent(container.NewBorder(top, nil, nil, nil, container.NewPadded(paintObject)))
+	addUndoShortcuts(paintWindow.Canvas(), paintObject)
 	paintWindow.Resize(fyne.NewSize(500, 600))
 	paintWindow.SetFixedSize(true)
 	return paintWindow
 }
+
+// newToolPalette creates the pen/eraser choice and the pen width selector
+func newToolPalette(paintObject *PaintWidget) fyne.CanvasObject {
+	toolRadio := widget.NewRadioGroup([]string{"Pen", "Eraser"}, func(s string) {
+		if s == "Eraser" {
+			paintObject.SetTool(EraserTool)
+		} else {
+			paintObject.SetTool(PenTool)
+		}
+	})
+	toolRadio.Horizontal = true
+	toolRadio.Required = true
+	if paintObject.tool == EraserTool {
+		toolRadio.SetSelected("Eraser")
+	} else {
+		toolRadio.SetSelected("Pen")
+	}
+
+	names := make([]string, len(penWidthOptions))
+	selected := ""
+	for i, option := range penWidthOptions {
+		names[i] = option.name
+		if option.cells == paintObject.penCells && (option.cells != 0 || option.pixels == paintObject.penWidth) {
+			selected = option.name
+		}
+	}
+	widthSelect := widget.NewSelect(names, func(s string) {
+		for _, option := range penWidthOptions {
+			if option.name != s {
+				continue
+			}
+			if option.cells > 0 {
+				paintObject.SetPenWidthInCells(option.cells)
+			} else {
+				paintObject.SetPenWidth(option.pixels)
+			}
+		}
+	})
+	if selected != "" {
+		widthSelect.SetSelected(selected)
+	}
+
+	return container.NewHBox(toolRadio, widget.NewLabel("Width:"), widthSelect)
+}
+
+// addUndoShortcuts binds Ctrl+Z to Undo and Ctrl+Shift+Z to Redo on the canvas
+func addUndoShortcuts(c fyne.Canvas, paintObject *PaintWidget) {
+	c.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) {
+		paintObject.Undo()
+	})
+	c.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift}, func(fyne.Shortcut) {
+		paintObject.Redo()
+	})
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/ehsan-torabi/Draw2Matrix/core"
+	"log"
+	"strconv"
+)
+
+// appID identifies the application to the preferences store, as in FyneApp.toml
+const appID = "com.Draw2Matrix"
+
+// Preference keys
+const (
+	prefDefaults = "defaults"         // Options that new sessions start with, as JSON
+	prefRemember = "rememberSettings" // Whether the settings are saved as defaults when quitting
+)
+
+// readDefaults decodes the defaults saved in the preferences into settings
+// Settings missing from the preferences keep their values, false is returned when
+// no defaults are saved
+func readDefaults(settings interface{}) bool {
+	data := mainApp.Preferences().String(prefDefaults)
+	if data == "" {
+		return false
+	}
+	if err := json.Unmarshal([]byte(data), settings); err != nil {
+		log.Println("preferences:", err)
+		return false
+	}
+	return true
+}
+
+// loadDefaults sets Options from the defaults saved in the preferences and shows them
+// Settings missing from the preferences keep their built-in defaults
+func loadDefaults() {
+	settings := Options
+	if !readDefaults(&settings) {
+		return
+	}
+	settings.SettingsSaved = false
+	Options = settings
+	showOptions()
+}
+
+// saveDefaults stores the current settings as the defaults of new sessions
+func saveDefaults() {
+	settings := Options
+	settings.SettingsSaved = false
+	writeDefaults(settings)
+}
+
+// writeDefaults stores settings as the defaults of new sessions
+func writeDefaults(settings interface{}) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		log.Println("preferences:", err)
+		return
+	}
+	mainApp.Preferences().SetString(prefDefaults, string(data))
+}
+
+// finishPreferences runs when the application quits and saves the settings as defaults if asked to
+func finishPreferences() {
+	if mainApp.Preferences().Bool(prefRemember) {
+		saveDefaults()
+	}
+}
+
+// preferencesOperation opens the preferences dialog
+// Its Defaults section edits the saved defaults, or the current settings when none are saved
+func preferencesOperation() {
+	settings := Options
+	readDefaults(&settings)
+
+	rows := widget.NewEntry()
+	cols := widget.NewEntry()
+	levels := widget.NewEntry()
+	values := widget.NewSelect(valueOptions, func(s string) {
+		setEnabled(levels, s == valueOptions[core.LevelValues])
+	})
+	csvCheck := widget.NewCheck("CSV", nil)
+	matlabCheck := widget.NewCheck("MATLAB (.m)", nil)
+	matCheck := widget.NewCheck("MAT-file", nil)
+	numpyCheck := widget.NewCheck("NumPy", nil)
+	idxCheck := widget.NewCheck("IDX", nil)
+	path := widget.NewEntry()
+	path.SetPlaceHolder("Directory path For save file")
+	dataName := widget.NewEntry()
+	targetName := widget.NewEntry()
+	browse := widget.NewButtonWithIcon("Browse", theme.FolderIcon(), func() {
+		dialog.ShowFolderOpen(func(uc fyne.ListableURI, err error) {
+			if err == nil && uc != nil {
+				path.SetText(uc.Path())
+			}
+		}, Application.mainWindow)
+	})
+
+	// fill shows settings in the fields
+	fill := func() {
+		rows.SetText(strconv.Itoa(settings.MatrixRow - 1))
+		cols.SetText(strconv.Itoa(settings.MatrixCol - 1))
+		if settings.ValueMode < 0 || settings.ValueMode >= len(valueOptions) {
+			settings.ValueMode = int(core.BinaryValues)
+		}
+		values.SetSelected(valueOptions[settings.ValueMode])
+		levels.SetText(strconv.Itoa(settings.ValueLevels))
+		csvCheck.SetChecked(settings.CSVSaveFormat)
+		matlabCheck.SetChecked(settings.MatlabSaveFormat)
+		matCheck.SetChecked(settings.MatFileSaveFormat)
+		numpyCheck.SetChecked(settings.NumpySaveFormat)
+		idxCheck.SetChecked(settings.IDXSaveFormat)
+		path.SetText(settings.SavePath)
+		dataName.SetText(settings.DataFileName)
+		targetName.SetText(settings.TargetFileName)
+	}
+	fill()
+
+	remember := widget.NewCheck("Remember the last used settings when quitting", func(b bool) {
+		mainApp.Preferences().SetBool(prefRemember, b)
+	})
+	remember.SetChecked(mainApp.Preferences().Bool(prefRemember))
+	info := widget.NewLabel("New sessions start with the defaults. A loaded project uses its own settings instead.")
+	saveBtn := widget.NewButtonWithIcon("Save Defaults", theme.DocumentSaveIcon(), func() {
+		row, err := strconv.Atoi(rows.Text)
+		col, colErr := strconv.Atoi(cols.Text)
+		if err != nil || colErr != nil || row <= 0 || col <= 0 {
+			dialog.ShowError(fmt.Errorf("matrix size: enter numbers"), Application.mainWindow)
+			return
+		}
+		valueLevels, err := strconv.Atoi(levels.Text)
+		if err != nil || valueLevels < 2 || valueLevels > 256 {
+			dialog.ShowError(fmt.Errorf("levels: enter number between 2 and 256"), Application.mainWindow)
+			return
+		}
+		settings.MatrixRow, settings.MatrixCol = row+1, col+1
+		settings.ValueMode = values.SelectedIndex()
+		settings.ValueLevels = valueLevels
+		settings.CSVSaveFormat = csvCheck.Checked
+		settings.MatlabSaveFormat = matlabCheck.Checked
+		settings.MatFileSaveFormat = matCheck.Checked
+		settings.NumpySaveFormat = numpyCheck.Checked
+		settings.IDXSaveFormat = idxCheck.Checked
+		settings.SavePath = path.Text
+		settings.DataFileName = dataName.Text
+		settings.TargetFileName = targetName.Text
+		settings.SettingsSaved = false
+		writeDefaults(settings)
+		statusLabel.Text = "Defaults saved!"
+		addLabelAnimation(statusLabel)
+	})
+	currentBtn := widget.NewButtonWithIcon("Use Current Settings", theme.ContentCopyIcon(), func() {
+		settings = Options
+		fill()
+	})
+	resetBtn := widget.NewButtonWithIcon("Restore Built-in Defaults", theme.ContentUndoIcon(), func() {
+		mainApp.Preferences().RemoveValue(prefDefaults)
+		statusLabel.Text = "Built-in defaults apply from the next start!"
+		addLabelAnimation(statusLabel)
+	})
+	form := widget.NewForm(
+		widget.NewFormItem("Matrix size", container.NewGridWithColumns(2, rows, cols)),
+		widget.NewFormItem("Cell values", container.NewGridWithColumns(2, values, levels)),
+		widget.NewFormItem("Save formats", container.NewGridWithColumns(3, csvCheck, matlabCheck, matCheck, numpyCheck, idxCheck)),
+		widget.NewFormItem("Save directory", container.NewBorder(nil, nil, nil, browse, path)),
+		widget.NewFormItem("Data file name", dataName),
+		widget.NewFormItem("Target file name", targetName),
+	)
+	content := container.NewVBox(
+		widget.NewLabelWithStyle("Defaults", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		info,
+		form,
+		remember,
+		container.NewGridWithColumns(3, saveBtn, currentBtn, resetBtn),
+	)
+	dialog.NewCustom("Preferences", "Close", content, Application.mainWindow).Show()
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/ehsan-torabi/Draw2Matrix/core"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sessionOrderOptions lists the choices of the session order, in the order of core.SessionOrder
+var sessionOrderOptions = []string{"Round-robin", "Random"}
+
+// sessionContainer holds the panel of the running session, hidden when there is none
+// It is created by newMainContent
+var sessionContainer *fyne.Container
+
+// SessionPanel prompts for the label to draw next and shows the progress of every label
+type SessionPanel struct {
+	session *core.Session
+	prompt  *canvas.Text
+	bars    []*widget.ProgressBar // One bar per label of the session
+	content fyne.CanvasObject
+}
+
+// NewSessionPanel creates the panel of a session
+func NewSessionPanel(s *core.Session) *SessionPanel {
+	p := &SessionPanel{session: s, prompt: canvas.NewText("", color.Black)}
+	p.prompt.TextSize = 24
+	p.prompt.TextStyle = fyne.TextStyle{Bold: true}
+	rows := make([]fyne.CanvasObject, 0, 2*len(s.Labels))
+	for _, label := range s.Labels {
+		bar := widget.NewProgressBar()
+		bar.Max = float64(s.Target)
+		bar.TextFormatter = func() string {
+			return fmt.Sprintf("%d / %d", int(bar.Value), s.Target)
+		}
+		p.bars = append(p.bars, bar)
+		rows = append(rows, widget.NewLabel(label), bar)
+	}
+	skip := widget.NewButtonWithIcon("Skip", theme.MediaSkipNextIcon(), nextSessionLabel)
+	end := widget.NewButtonWithIcon("End Session", theme.CancelIcon(), endSession)
+	p.content = container.NewVBox(
+		container.NewBorder(nil, nil, nil, container.NewHBox(skip, end), p.prompt),
+		container.NewGridWithColumns(4, rows...),
+	)
+	p.Refresh()
+	return p
+}
+
+// Refresh updates the progress bars from the current dataset
+func (p *SessionPanel) Refresh() {
+	for i, count := range p.session.Counts(CurrentDataset) {
+		p.bars[i].SetValue(float64(count))
+	}
+	if label := p.session.Current(); label != "" {
+		p.prompt.Text = "Draw: " + label
+	} else {
+		p.prompt.Text = ""
+	}
+	p.prompt.Refresh()
+}
+
+// startSessionOperation asks for the labels and quota of a collection session and starts it
+func startSessionOperation() {
+	if !Options.SettingsSaved {
+		dialog.ShowError(fmt.Errorf("please first save settings"), Application.mainWindow)
+		return
+	}
+	labelsEntry := widget.NewMultiLineEntry()
+	labelsEntry.SetPlaceHolder("A, B, C")
+	targetEntry := widget.NewEntry()
+	targetEntry.SetText("10")
+	orderSelect := widget.NewSelect(sessionOrderOptions, nil)
+	orderSelect.SetSelected(sessionOrderOptions[0])
+	items := []*widget.FormItem{
+		widget.NewFormItem("Labels", labelsEntry),
+		widget.NewFormItem("Samples per label", targetEntry),
+		widget.NewFormItem("Order", orderSelect),
+	}
+	dialog.ShowForm("Start session", "Start", "Cancel", items, func(b bool) {
+		if !b {
+			return
+		}
+		target, err := strconv.Atoi(targetEntry.Text)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("samples per label: enter number"), Application.mainWindow)
+			return
+		}
+		s, err := core.NewSession(parseSessionLabels(labelsEntry.Text), target,
+			core.SessionOrder(orderSelect.SelectedIndex()), time.Now().UnixNano())
+		if err != nil {
+			dialog.ShowError(err, Application.mainWindow)
+			return
+		}
+		if s.Done(CurrentDataset) {
+			dialog.ShowInformation("Start session", "Every label already has enough samples.", Application.mainWindow)
+			return
+		}
+		Application.session = NewSessionPanel(s)
+		sessionContainer.Objects = []fyne.CanvasObject{Application.session.content}
+		sessionContainer.Show()
+		sessionContainer.Refresh()
+		input.Disable()
+		nextSessionLabel()
+	}, Application.mainWindow)
+}
+
+// parseSessionLabels splits a list of labels separated by commas or new lines
+func parseSessionLabels(s string) []string {
+	labels := make([]string, 0)
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if label := strings.TrimSpace(field); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// nextSessionLabel moves the session to the next label and puts it in the label input
+// The session ends once every quota is met
+func nextSessionLabel() {
+	p := Application.session
+	if p == nil {
+		return
+	}
+	label := p.session.Next(CurrentDataset)
+	if label == "" {
+		endSession()
+		dialog.ShowInformation("Session complete", "Every label has reached its target.", Application.mainWindow)
+		return
+	}
+	input.SetText(label)
+	p.Refresh()
+}
+
+// endSession closes the session panel and gives the label input back to the annotator
+func endSession() {
+	Application.session = nil
+	sessionContainer.Objects = nil
+	sessionContainer.Hide()
+	input.Enable()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/ehsan-torabi/Draw2Matrix/core"
+	"strconv"
+	"strings"
+)
+
+// StatsWindow shows the number of samples, ink density and mean image of every
+// label with warnings about imbalance and duplicates, to judge when a dataset is ready
+type StatsWindow struct {
+	window   fyne.Window
+	summary  *widget.Label
+	warnings *widget.Label
+	classes  *fyne.Container
+	repeats  *widget.Label
+}
+
+// NewStatsWindow creates the statistics window for the current dataset
+func NewStatsWindow(a fyne.App) *StatsWindow {
+	s := &StatsWindow{
+		window:   a.NewWindow("Statistics"),
+		summary:  widget.NewLabel(""),
+		warnings: widget.NewLabel(""),
+		classes:  container.NewGridWrap(fyne.NewSize(120, 160)),
+		repeats:  widget.NewLabel(""),
+	}
+	s.warnings.Wrapping = fyne.TextWrapWord
+	s.repeats.Wrapping = fyne.TextWrapWord
+	s.window.SetContent(container.NewBorder(
+		container.NewPadded(container.NewVBox(s.summary, s.warnings)),
+		nil, nil, nil,
+		container.NewVScroll(container.NewVBox(
+			widget.NewLabel("Classes (mean image):"),
+			s.classes,
+			widget.NewLabel("Duplicates:"),
+			s.repeats,
+		)),
+	))
+	s.window.Resize(fyne.NewSize(640, 520))
+	s.window.SetOnClosed(func() {
+		if Application.stats == s {
+			Application.stats = nil
+		}
+	})
+	s.Refresh()
+	return s
+}
+
+// Show opens the statistics window
+func (s *StatsWindow) Show() {
+	s.window.Show()
+}
+
+// Refresh computes the statistics of the current dataset again
+func (s *StatsWindow) Refresh() {
+	stats := CurrentDataset.Stats()
+	s.summary.SetText(fmt.Sprintf("%d samples, %d labels, %d duplicates",
+		stats.Total, len(stats.Classes), stats.DuplicateCount()))
+	if warnings := stats.Warnings(); len(warnings) > 0 {
+		s.warnings.SetText("⚠ " + strings.Join(warnings, "\n⚠ "))
+	} else if stats.Total > 0 {
+		s.warnings.SetText("No warnings: the labels are balanced and there are no duplicates.")
+	} else {
+		s.warnings.SetText("No samples yet.")
+	}
+
+	cards := make([]fyne.CanvasObject, len(stats.Classes))
+	for i, class := range stats.Classes {
+		heatmap := canvas.NewImageFromImage(core.HeatmapImage(class.Mean))
+		heatmap.FillMode = canvas.ImageFillContain
+		heatmap.ScaleMode = canvas.ImageScalePixels
+		heatmap.SetMinSize(fyne.NewSize(96, 96))
+		text := fmt.Sprintf("%s: %d\nink %.0f%%", class.Label, class.Originals, class.Density*100)
+		if class.Augmented > 0 {
+			text = fmt.Sprintf("%s: %d +%d aug\nink %.0f%%", class.Label, class.Originals, class.Augmented, class.Density*100)
+		}
+		cards[i] = container.NewBorder(nil, widget.NewLabel(text), nil, nil, heatmap)
+	}
+	s.classes.Objects = cards
+	s.classes.Refresh()
+
+	lines := make([]string, len(stats.Duplicates))
+	for i, group := range stats.Duplicates {
+		numbers := make([]string, len(group.Indices))
+		for j, index := range group.Indices {
+			numbers[j] = "#" + strconv.Itoa(index+1)
+		}
+		lines[i] = fmt.Sprintf("Samples %s: %s", strings.Join(numbers, ", "), strings.Join(group.Labels, ", "))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "None")
+	}
+	s.repeats.SetText(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/ehsan-torabi/Draw2Matrix/core"
+)
+
+// ThresholdPreview shows the current drawing converted with every threshold method
+// side by side, so the method that fits the glyphs can be picked in the settings
+type ThresholdPreview struct {
+	window fyne.Window
+	images []*canvas.Image // One preview per core.ThresholdMethod
+}
+
+// NewThresholdPreview creates the threshold comparison window
+func NewThresholdPreview(a fyne.App) *ThresholdPreview {
+	t := &ThresholdPreview{window: a.NewWindow("Threshold Preview")}
+	columns := make([]fyne.CanvasObject, len(thresholdOptions))
+	for i, name := range thresholdOptions {
+		preview := canvas.NewImageFromImage(nil)
+		preview.FillMode = canvas.ImageFillContain
+		preview.ScaleMode = canvas.ImageScalePixels
+		preview.SetMinSize(fyne.NewSize(120, 120))
+		t.images = append(t.images, preview)
+		columns[i] = container.NewBorder(nil, widget.NewLabel(name), nil, nil, preview)
+	}
+	t.window.SetContent(container.NewPadded(container.NewGridWithColumns(len(columns), columns...)))
+	t.window.SetOnClosed(func() {
+		if Application.thresholds == t {
+			Application.thresholds = nil
+		}
+	})
+	t.Refresh()
+	return t
+}
+
+// Show opens the preview window
+func (t *ThresholdPreview) Show() {
+	t.window.Show()
+}
+
+// Refresh converts the current drawing again with the current settings
+func (t *ThresholdPreview) Refresh() {
+	rows, cols := matrixSize()
+	if rows <= 0 || cols <= 0 {
+		return
+	}
+	drawing := renderDrawing(Application.paintObject)
+	r := currentRasterizer()
+	for i, preview := range t.images {
+		r.Threshold.Method = core.ThresholdMethod(i)
+		preview.Image = r.Process(drawing)
+		preview.Refresh()
+	}
+}